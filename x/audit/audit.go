@@ -0,0 +1,68 @@
+package audit
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	defaultRecorderMu sync.Mutex
+	defaultRecorder   *Recorder
+)
+
+// Configure configures the Recorder used by the package-level functions. Any
+// previously configured Recorder is closed, flushing its buffered events.
+func Configure(opts ...Option) error {
+	defaultRecorderMu.Lock()
+	defer defaultRecorderMu.Unlock()
+
+	previous := defaultRecorder
+	defaultRecorder = NewRecorder(opts...)
+
+	if previous != nil {
+		return previous.Close(context.Background())
+	}
+
+	return nil
+}
+
+// DefaultRecorder returns the Recorder used by the package-level functions.
+// If Configure has not been called, a Recorder that writes to standard output
+// is created.
+func DefaultRecorder() *Recorder {
+	defaultRecorderMu.Lock()
+	defer defaultRecorderMu.Unlock()
+
+	if defaultRecorder == nil {
+		defaultRecorder = NewRecorder()
+	}
+
+	return defaultRecorder
+}
+
+// Record records an event with the default Recorder. See Recorder.Record.
+func Record(ctx context.Context, action, target string, details map[string]interface{}) error {
+	return DefaultRecorder().Record(ctx, action, target, details)
+}
+
+// Flush writes the events buffered by the default Recorder to its sinks.
+func Flush(ctx context.Context) error {
+	return DefaultRecorder().Flush(ctx)
+}
+
+// Close closes the default Recorder, flushing its buffered events. Call it
+// when the service shuts down. Lambda functions should instead call Flush at
+// the end of each invocation, as the execution environment may be frozen
+// before a background flush.
+func Close(ctx context.Context) error {
+	defaultRecorderMu.Lock()
+	recorder := defaultRecorder
+	defaultRecorder = nil
+	defaultRecorderMu.Unlock()
+
+	if recorder == nil {
+		return nil
+	}
+
+	return recorder.Close(ctx)
+}
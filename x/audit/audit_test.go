@@ -0,0 +1,64 @@
+package audit_test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/audit/audittest"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigure(t *testing.T) {
+	t.Cleanup(func() { _ = audit.Close(context.Background()) })
+
+	first := audittest.NewSink()
+	require.NoError(t, audit.Configure(audit.WithSink(first)))
+	require.NoError(t, audit.Record(newRequestContext(), "survey.create", "1", nil))
+
+	// Reconfiguring flushes events buffered by the previous recorder.
+	second := audittest.NewSink()
+	require.NoError(t, audit.Configure(audit.WithSink(second)))
+	assert.Len(t, first.Events(), 1)
+
+	require.NoError(t, audit.Record(newRequestContext(), "survey.create", "2", nil))
+	require.NoError(t, audit.Flush(context.Background()))
+	require.Len(t, second.Events(), 1)
+	assert.Equal(t, "2", second.Events()[0].Target)
+
+	require.NoError(t, audit.Close(context.Background()))
+	assert.NoError(t, audit.Close(context.Background()))
+}
+
+func ExampleRecord() {
+	err := audit.Configure(audit.WithSink(audit.SinkFunc(func(ctx context.Context, events []audit.Event) error {
+		for _, event := range events {
+			fmt.Println(event.Actor.UserID, event.Action, event.Target, event.Details["reason"])
+		}
+		return nil
+	})))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return
+	}
+
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+		CustomerAccountID: "123",
+		UserID:            "456",
+	})
+
+	if err := audit.Record(ctx, "survey.delete", "survey-1", map[string]interface{}{"reason": "duplicate"}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	if err := audit.Close(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+	}
+
+	// Output:
+	// 456 survey.delete survey-1 duplicate
+}
@@ -0,0 +1,177 @@
+package audittest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cultureamp/ca-go/x/audit"
+)
+
+// Sink is an audit.Sink that keeps events in memory.
+type Sink struct {
+	mu     sync.Mutex
+	events []audit.Event
+	err    error
+}
+
+// NewSink returns an empty Sink.
+func NewSink() *Sink {
+	return &Sink{}
+}
+
+// Write implements audit.Sink. It returns the error set by FailWith, if any,
+// without keeping the events.
+func (s *Sink) Write(ctx context.Context, events []audit.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.events = append(s.events, events...)
+	return nil
+}
+
+// FailWith makes subsequent writes fail with err. Pass nil to make writes
+// succeed again.
+func (s *Sink) FailWith(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.err = err
+}
+
+// Events returns the events written to the sink, in order.
+func (s *Sink) Events() []audit.Event {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]audit.Event(nil), s.events...)
+}
+
+// Queue is a local fake of an SQS queue and a Kinesis data stream, which
+// implements both audit.SQSAPI and audit.KinesisAPI. Messages and records are
+// kept in memory and can be decoded back to events.
+type Queue struct {
+	mu       sync.Mutex
+	messages [][]byte
+	calls    int
+	failing  map[int]bool
+}
+
+// NewQueue returns an empty Queue.
+func NewQueue() *Queue {
+	return &Queue{failing: map[int]bool{}}
+}
+
+// FailEntry makes the entry at the given index of every subsequent batch fail,
+// as SQS and Kinesis do when an entry is throttled or rejected.
+func (q *Queue) FailEntry(index int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.failing[index] = true
+}
+
+// ClearFailures makes every entry of subsequent batches succeed again.
+func (q *Queue) ClearFailures() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.failing = map[int]bool{}
+}
+
+// SendMessageBatchWithContext implements audit.SQSAPI.
+func (q *Queue) SendMessageBatchWithContext(ctx aws.Context, input *sqs.SendMessageBatchInput, opts ...awsrequest.Option) (*sqs.SendMessageBatchOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(input.Entries) > 10 {
+		return nil, fmt.Errorf("too many entries in batch: %d", len(input.Entries))
+	}
+
+	q.calls++
+
+	output := &sqs.SendMessageBatchOutput{}
+	for i, entry := range input.Entries {
+		if q.failing[i] {
+			output.Failed = append(output.Failed, &sqs.BatchResultErrorEntry{
+				Id:      entry.Id,
+				Code:    aws.String("InternalError"),
+				Message: aws.String("entry " + strconv.Itoa(i) + " failed"),
+			})
+			continue
+		}
+
+		q.messages = append(q.messages, []byte(aws.StringValue(entry.MessageBody)))
+		output.Successful = append(output.Successful, &sqs.SendMessageBatchResultEntry{Id: entry.Id})
+	}
+
+	return output, nil
+}
+
+// PutRecordsWithContext implements audit.KinesisAPI.
+func (q *Queue) PutRecordsWithContext(ctx aws.Context, input *kinesis.PutRecordsInput, opts ...awsrequest.Option) (*kinesis.PutRecordsOutput, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(input.Records) > 500 {
+		return nil, fmt.Errorf("too many records in batch: %d", len(input.Records))
+	}
+
+	q.calls++
+
+	output := &kinesis.PutRecordsOutput{FailedRecordCount: aws.Int64(0)}
+	for i, record := range input.Records {
+		if aws.StringValue(record.PartitionKey) == "" {
+			return nil, fmt.Errorf("record %d has no partition key", i)
+		}
+
+		if q.failing[i] {
+			output.FailedRecordCount = aws.Int64(aws.Int64Value(output.FailedRecordCount) + 1)
+			output.Records = append(output.Records, &kinesis.PutRecordsResultEntry{
+				ErrorCode: aws.String("InternalFailure"),
+			})
+			continue
+		}
+
+		q.messages = append(q.messages, record.Data)
+		output.Records = append(output.Records, &kinesis.PutRecordsResultEntry{})
+	}
+
+	return output, nil
+}
+
+// Calls returns the number of batch requests made to the queue.
+func (q *Queue) Calls() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.calls
+}
+
+// Events decodes the messages or records accepted by the queue, in order.
+func (q *Queue) Events() ([]audit.Event, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	events := make([]audit.Event, 0, len(q.messages))
+	for _, message := range q.messages {
+		var event audit.Event
+		if err := json.Unmarshal(message, &event); err != nil {
+			return nil, fmt.Errorf("decode audit event: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,16 @@
+// Package audittest provides fakes for testing code that records audit events
+// with the audit package.
+//
+// Sink keeps events in memory, so tests can assert on the audit trail a code
+// path produced:
+//   sink := audittest.NewSink()
+//   recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithBatchSize(1))
+//   ...
+//
+//   assert.Equal(t, "survey.delete", sink.Events()[0].Action)
+//
+// Queue is a local fake of SQS and Kinesis, for exercising audit.SQSSink and
+// audit.KinesisSink without AWS:
+//   queue := audittest.NewQueue()
+//   recorder := audit.NewRecorder(audit.WithSink(audit.NewSQSSink(queue, "queue-url")))
+package audittest
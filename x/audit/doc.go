@@ -0,0 +1,40 @@
+// Package audit records an audit trail of who did what. Each Event records an
+// action performed on a target, with the actor and request identifiers taken
+// from the request-scoped attributes in the context: the AuthenticatedUser
+// (including the real user when impersonating), the AuthenticatedService,
+// RequestIDs and RequestMetadata.
+//
+// Events are recorded with Record, which uses a Recorder configured once with
+// Configure:
+//   err := audit.Configure(
+//     audit.WithSink(audit.NewSQSSink(sqs.New(sess), os.Getenv("AUDIT_QUEUE_URL"))))
+//   if err != nil {
+//     // handle configuration error
+//   }
+//   defer audit.Close(ctx)
+//
+//   err = audit.Record(ctx, "survey.delete", surveyID, map[string]interface{}{
+//     "reason": "duplicate",
+//   })
+//
+// If Configure is not called, events are written to standard output as JSON
+// lines.
+//
+// Events are buffered and written to every configured Sink in batches, when a
+// batch is full and periodically in the background. Buffered events are
+// written by Flush and Close, so a service must call Close when it shuts down.
+// Lambda functions should call Flush before returning from each invocation.
+// Events a sink fails to write are kept and retried on the next flush, and
+// sinks that write in batches, like SQSSink and KinesisSink, report the
+// individual events that failed with a WriteError.
+//
+// Sinks are provided for JSON lines on standard output or any io.Writer,
+// files, SQS queues and Kinesis data streams. Any other destination can be
+// supported by implementing Sink. The audittest package provides an in-memory
+// Sink and a local fake of SQS and Kinesis for tests.
+//
+// A Recorder can also audit the writes made by impersonated requests, with
+// request.NewImpersonationHTTPMiddleware:
+//   mw := request.NewImpersonationHTTPMiddleware(
+//     request.WithImpersonationAuditor(audit.DefaultRecorder().ImpersonationAuditor()))
+package audit
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	"github.com/cultureamp/ca-go/x/request"
+)
+
+// Event is an entry in the audit trail, recording that an actor performed an
+// action on a target.
+type Event struct {
+	// ID uniquely identifies the event, so that consumers can discard
+	// duplicates delivered by at-least-once sinks.
+	ID string `json:"id"`
+	// Time is when the event was recorded, in UTC.
+	Time time.Time `json:"time"`
+	// Action is what was done, for example "survey.delete".
+	Action string `json:"action"`
+	// Target identifies what it was done to, for example a survey ID.
+	Target string `json:"target,omitempty"`
+	// Actor is who did it.
+	Actor Actor `json:"actor"`
+	// RequestID and CorrelationID link the event to the request that
+	// caused it.
+	RequestID     string `json:"requestID,omitempty"`
+	CorrelationID string `json:"correlationID,omitempty"`
+	// Details are additional attributes of the action.
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+// Actor identifies who performed an audited action.
+type Actor struct {
+	// UserID and AccountID identify the effective user and their account.
+	UserID    string `json:"userID,omitempty"`
+	AccountID string `json:"accountID,omitempty"`
+	// RealUserID is the user who performed the action while impersonating
+	// UserID, and Impersonated is true when they differ.
+	RealUserID   string `json:"realUserID,omitempty"`
+	Impersonated bool   `json:"impersonated,omitempty"`
+	// Service is the name of the calling service, for actions performed by
+	// another service.
+	Service string `json:"service,omitempty"`
+	// SourceIP is the IP address the request came from, as read into the
+	// RequestMetadata. It is only reliable if the service trusts the right
+	// number of proxies; see request.WithTrustedProxies.
+	SourceIP string `json:"sourceIP,omitempty"`
+}
+
+// NewEvent returns an Event for the action, with the actor and request
+// identifiers taken from the AuthenticatedUser, AuthenticatedService,
+// RequestIDs and RequestMetadata in the context.
+func NewEvent(ctx context.Context, action, target string, details map[string]interface{}) Event {
+	event := Event{
+		ID:      request.NewID(),
+		Time:    time.Now().UTC(),
+		Action:  action,
+		Target:  target,
+		Details: details,
+	}
+
+	if user, ok := request.AuthenticatedUserFromContext(ctx); ok {
+		event.Actor.UserID = user.UserID
+		event.Actor.AccountID = user.CustomerAccountID
+		event.Actor.RealUserID = user.RealUserID
+		event.Actor.Impersonated = user.IsImpersonated()
+	}
+
+	if service, ok := request.AuthenticatedServiceFromContext(ctx); ok {
+		event.Actor.Service = service.Name
+	}
+
+	if metadata, ok := request.RequestMetadataFromContext(ctx); ok {
+		event.Actor.SourceIP = metadata.SourceIP
+	}
+
+	if ids, ok := request.RequestIDsFromContext(ctx); ok {
+		event.RequestID = ids.RequestID
+		event.CorrelationID = ids.CorrelationID
+	}
+
+	return event
+}
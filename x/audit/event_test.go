@@ -0,0 +1,63 @@
+package audit_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestContext() context.Context {
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+		CustomerAccountID: "123",
+		UserID:            "456",
+		RealUserID:        "789",
+	})
+	ctx = request.ContextWithRequestIDs(ctx, request.RequestIDs{
+		RequestID:     "abc",
+		CorrelationID: "def",
+	})
+
+	return ctx
+}
+
+func TestNewEvent(t *testing.T) {
+	t.Run("takes the actor from the context", func(t *testing.T) {
+		ctx := request.ContextWithAuthenticatedService(newRequestContext(), request.AuthenticatedService{Name: "murmur"})
+		ctx = request.ContextWithRequestMetadata(ctx, request.RequestMetadata{SourceIP: "203.0.113.7"})
+
+		event := audit.NewEvent(ctx, "survey.delete", "survey-1", map[string]interface{}{"reason": "duplicate"})
+
+		assert.NotEmpty(t, event.ID)
+		assert.False(t, event.Time.IsZero())
+		assert.Equal(t, "survey.delete", event.Action)
+		assert.Equal(t, "survey-1", event.Target)
+		assert.Equal(t, map[string]interface{}{"reason": "duplicate"}, event.Details)
+		assert.Equal(t, audit.Actor{
+			UserID:       "456",
+			AccountID:    "123",
+			RealUserID:   "789",
+			Impersonated: true,
+			Service:      "murmur",
+			SourceIP:     "203.0.113.7",
+		}, event.Actor)
+		assert.Equal(t, "abc", event.RequestID)
+		assert.Equal(t, "def", event.CorrelationID)
+	})
+
+	t.Run("records events without an actor", func(t *testing.T) {
+		event := audit.NewEvent(context.Background(), "job.run", "", nil)
+
+		assert.Equal(t, audit.Actor{}, event.Actor)
+		assert.Empty(t, event.RequestID)
+	})
+
+	t.Run("generates unique IDs", func(t *testing.T) {
+		first := audit.NewEvent(context.Background(), "job.run", "", nil)
+		second := audit.NewEvent(context.Background(), "job.run", "", nil)
+
+		assert.NotEqual(t, first.ID, second.ID)
+	})
+}
@@ -0,0 +1,295 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cultureamp/ca-go/x/request"
+)
+
+const (
+	defaultBatchSize     = 25
+	defaultFlushInterval = 5 * time.Second
+
+	// maxRetainedEvents limits the events kept for retry for each sink, so
+	// that a sink that is down for a long time doesn't exhaust memory.
+	maxRetainedEvents = 10000
+)
+
+// ErrRecorderClosed is returned when an event is recorded after the Recorder
+// has been closed.
+var ErrRecorderClosed = errors.New("audit recorder is closed")
+
+// ErrorHandler is called with errors from sinks when events are flushed in
+// the background, since they cannot be returned to the caller of Record.
+type ErrorHandler func(err error)
+
+// defaultErrorHandler writes the error to standard error, so that failures to
+// write the audit trail are visible in the service's logs.
+func defaultErrorHandler(err error) {
+	fmt.Fprintf(os.Stderr, "audit: %v\n", err)
+}
+
+type config struct {
+	sinks         []Sink
+	batchSize     int
+	flushInterval time.Duration
+	errorHandler  ErrorHandler
+}
+
+// Option is a function type that can be provided to NewRecorder to configure
+// how events are batched and where they are written.
+type Option func(c *config)
+
+// WithSink adds a sink that events are written to. Events are written to
+// every sink. If no sinks are configured, events are written to standard
+// output.
+func WithSink(sink Sink) Option {
+	return func(c *config) {
+		c.sinks = append(c.sinks, sink)
+	}
+}
+
+// WithBatchSize configures the number of buffered events that triggers a
+// flush. The default is 25. A size of 1 writes each event as it is recorded.
+func WithBatchSize(size int) Option {
+	return func(c *config) {
+		c.batchSize = size
+	}
+}
+
+// WithFlushInterval configures how often buffered events are flushed in the
+// background. The default is 5 seconds. An interval of 0 disables background
+// flushing, so events are only written when a batch is full or Flush is
+// called.
+func WithFlushInterval(interval time.Duration) Option {
+	return func(c *config) {
+		c.flushInterval = interval
+	}
+}
+
+// WithErrorHandler configures the handler called with errors from background
+// flushes. By default, errors are written to standard error.
+func WithErrorHandler(handler ErrorHandler) Option {
+	return func(c *config) {
+		c.errorHandler = handler
+	}
+}
+
+// Recorder buffers audit events and writes them to its sinks in batches. It
+// is safe for concurrent use. Close must be called on shutdown so that
+// buffered events are not lost.
+type Recorder struct {
+	cfg config
+
+	mu     sync.Mutex
+	buffer []Event
+	closed bool
+
+	// flushMu serialises writes to the sinks, so events are written in the
+	// order they were recorded. It also guards retained.
+	flushMu sync.Mutex
+
+	// retained holds, for each sink, the events it failed to write, which
+	// are retried on the next flush.
+	retained [][]Event
+
+	done    chan struct{}
+	stopped sync.WaitGroup
+}
+
+// NewRecorder returns a Recorder configured with the given options, and
+// starts flushing in the background.
+func NewRecorder(opts ...Option) *Recorder {
+	cfg := config{
+		batchSize:     defaultBatchSize,
+		flushInterval: defaultFlushInterval,
+		errorHandler:  defaultErrorHandler,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if len(cfg.sinks) == 0 {
+		cfg.sinks = []Sink{NewStdoutSink()}
+	}
+
+	if cfg.batchSize < 1 {
+		cfg.batchSize = 1
+	}
+
+	r := &Recorder{
+		cfg:      cfg,
+		retained: make([][]Event, len(cfg.sinks)),
+		done:     make(chan struct{}),
+	}
+
+	if cfg.flushInterval > 0 {
+		r.stopped.Add(1)
+		go r.flushPeriodically()
+	}
+
+	return r
+}
+
+// Record records that the actor in the context performed the action on the
+// target. The event is buffered, and written to the sinks when the batch is
+// full, on the next background flush, or when Flush or Close is called. If
+// the batch is full, Record writes it before returning and returns any error
+// from the sinks. The batch holds the events of other requests, so it is
+// written with a background context rather than ctx, and isn't abandoned if
+// this request is cancelled.
+func (r *Recorder) Record(ctx context.Context, action, target string, details map[string]interface{}) error {
+	if action == "" {
+		return errors.New("record audit event: action is required")
+	}
+
+	return r.RecordEvent(ctx, NewEvent(ctx, action, target, details))
+}
+
+// RecordEvent records an event constructed by the caller, for example with
+// NewEvent.
+func (r *Recorder) RecordEvent(ctx context.Context, event Event) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return ErrRecorderClosed
+	}
+
+	r.buffer = append(r.buffer, event)
+	full := len(r.buffer) >= r.cfg.batchSize
+	r.mu.Unlock()
+
+	if full {
+		return r.Flush(context.Background())
+	}
+
+	return nil
+}
+
+// Flush writes all buffered events to the sinks. An error is returned if any
+// sink fails; the events are still written to the other sinks. The events a
+// sink failed to write are kept and written to that sink again, before any
+// newer events, on the next flush. See WriteError.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.flushMu.Lock()
+	defer r.flushMu.Unlock()
+
+	r.mu.Lock()
+	events := r.buffer
+	r.buffer = nil
+	r.mu.Unlock()
+
+	var firstErr error
+	for i, sink := range r.cfg.sinks {
+		pending := append(r.retained[i], events...)
+		r.retained[i] = nil
+
+		if len(pending) == 0 {
+			continue
+		}
+
+		err := sink.Write(ctx, pending)
+		if err == nil {
+			continue
+		}
+
+		failed := pending
+		var werr *WriteError
+		if errors.As(err, &werr) {
+			failed = werr.Failed
+		}
+
+		dropped := r.retain(i, failed)
+
+		if firstErr == nil {
+			firstErr = fmt.Errorf("flush %d audit events: %w", len(pending), err)
+			if dropped > 0 {
+				firstErr = fmt.Errorf("%w (%d events dropped after too many failures)", firstErr, dropped)
+			}
+		}
+	}
+
+	return firstErr
+}
+
+// retain keeps the events that the sink at index i failed to write, up to
+// maxRetainedEvents, returning the number of the oldest events dropped.
+func (r *Recorder) retain(i int, failed []Event) int {
+	dropped := 0
+	if len(failed) > maxRetainedEvents {
+		dropped = len(failed) - maxRetainedEvents
+		failed = failed[dropped:]
+	}
+
+	r.retained[i] = append([]Event(nil), failed...)
+
+	return dropped
+}
+
+// Close stops background flushing, flushes buffered events, and closes any
+// sinks that implement io.Closer. Events recorded after Close return
+// ErrRecorderClosed.
+func (r *Recorder) Close(ctx context.Context) error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	r.mu.Unlock()
+
+	close(r.done)
+	r.stopped.Wait()
+
+	err := r.Flush(ctx)
+
+	for _, sink := range r.cfg.sinks {
+		if closer, ok := sink.(io.Closer); ok {
+			if closeErr := closer.Close(); closeErr != nil && err == nil {
+				err = fmt.Errorf("close audit sink: %w", closeErr)
+			}
+		}
+	}
+
+	return err
+}
+
+// ImpersonationAuditor returns a request.ImpersonationAuditor that records an
+// "impersonation.write" event for each impersonated write, targeting the
+// operation. Supply it to request.NewImpersonationHTTPMiddleware:
+//   mw := request.NewImpersonationHTTPMiddleware(
+//     request.WithImpersonationAuditor(recorder.ImpersonationAuditor()))
+func (r *Recorder) ImpersonationAuditor() request.ImpersonationAuditor {
+	return func(ctx context.Context, event request.ImpersonationEvent) {
+		auditEvent := NewEvent(ctx, "impersonation.write", event.Operation, nil)
+		auditEvent.Time = event.Time
+
+		if err := r.RecordEvent(ctx, auditEvent); err != nil {
+			r.cfg.errorHandler(err)
+		}
+	}
+}
+
+func (r *Recorder) flushPeriodically() {
+	defer r.stopped.Done()
+
+	ticker := time.NewTicker(r.cfg.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-ticker.C:
+			if err := r.Flush(context.Background()); err != nil {
+				r.cfg.errorHandler(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package audit_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/audit/audittest"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	t.Run("buffers events until the batch is full", func(t *testing.T) {
+		sink := audittest.NewSink()
+		recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithBatchSize(3), audit.WithFlushInterval(0))
+
+		ctx := newRequestContext()
+		require.NoError(t, recorder.Record(ctx, "survey.create", "1", nil))
+		require.NoError(t, recorder.Record(ctx, "survey.create", "2", nil))
+		assert.Empty(t, sink.Events())
+
+		require.NoError(t, recorder.Record(ctx, "survey.create", "3", nil))
+
+		events := sink.Events()
+		require.Len(t, events, 3)
+		for i, target := range []string{"1", "2", "3"} {
+			assert.Equal(t, target, events[i].Target)
+			assert.Equal(t, "456", events[i].Actor.UserID)
+		}
+	})
+
+	t.Run("writes to every sink", func(t *testing.T) {
+		first, second := audittest.NewSink(), audittest.NewSink()
+		recorder := audit.NewRecorder(audit.WithSink(first), audit.WithSink(second), audit.WithFlushInterval(0))
+
+		require.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+		require.NoError(t, recorder.Flush(context.Background()))
+
+		assert.Len(t, first.Events(), 1)
+		assert.Len(t, second.Events(), 1)
+	})
+
+	t.Run("returns sink errors from Flush", func(t *testing.T) {
+		failing, working := audittest.NewSink(), audittest.NewSink()
+		failing.FailWith(errors.New("sink unavailable"))
+		recorder := audit.NewRecorder(audit.WithSink(failing), audit.WithSink(working), audit.WithFlushInterval(0))
+
+		require.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+
+		err := recorder.Flush(context.Background())
+		assert.ErrorContains(t, err, "sink unavailable")
+		assert.Len(t, working.Events(), 1)
+	})
+
+	t.Run("retries failed events on the next flush", func(t *testing.T) {
+		failing, working := audittest.NewSink(), audittest.NewSink()
+		failing.FailWith(errors.New("sink unavailable"))
+		recorder := audit.NewRecorder(audit.WithSink(failing), audit.WithSink(working), audit.WithFlushInterval(0))
+
+		require.NoError(t, recorder.Record(context.Background(), "survey.create", "1", nil))
+		require.Error(t, recorder.Flush(context.Background()))
+
+		failing.FailWith(nil)
+		require.NoError(t, recorder.Record(context.Background(), "survey.create", "2", nil))
+		require.NoError(t, recorder.Flush(context.Background()))
+
+		var targets []string
+		for _, event := range failing.Events() {
+			targets = append(targets, event.Target)
+		}
+		assert.Equal(t, []string{"1", "2"}, targets)
+		assert.Len(t, working.Events(), 2, "events are not written twice to sinks that succeeded")
+	})
+
+	t.Run("retries only the entries that failed", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		queue.FailEntry(0)
+		recorder := audit.NewRecorder(audit.WithSink(audit.NewSQSSink(queue, "queue-url")), audit.WithFlushInterval(0))
+
+		var recorded []audit.Event
+		for _, event := range newEvents(12) {
+			recorded = append(recorded, event)
+			require.NoError(t, recorder.RecordEvent(context.Background(), event))
+		}
+
+		err := recorder.Flush(context.Background())
+		var werr *audit.WriteError
+		require.ErrorAs(t, err, &werr)
+		assert.Equal(t, []audit.Event{recorded[0], recorded[10]}, werr.Failed)
+
+		sent, err := queue.Events()
+		require.NoError(t, err)
+		assert.Len(t, sent, 10, "the batches after a failure are still sent")
+
+		queue.ClearFailures()
+		require.NoError(t, recorder.Flush(context.Background()))
+
+		sent, err = queue.Events()
+		require.NoError(t, err)
+		assert.ElementsMatch(t, recorded, sent)
+	})
+
+	t.Run("does not retry events that can't be encoded", func(t *testing.T) {
+		var buf bytes.Buffer
+		recorder := audit.NewRecorder(audit.WithSink(audit.NewJSONSink(&buf)), audit.WithFlushInterval(0))
+
+		events := newEvents(2)
+		events[0].Details = map[string]interface{}{"score": math.NaN()}
+		for _, event := range events {
+			require.NoError(t, recorder.RecordEvent(context.Background(), event))
+		}
+
+		require.Error(t, recorder.Flush(context.Background()))
+
+		require.NoError(t, recorder.RecordEvent(context.Background(), newEvents(1)[0]))
+		require.NoError(t, recorder.Flush(context.Background()))
+
+		written := decodeLines(t, buf.Bytes())
+		require.Len(t, written, 2, "events are written once")
+		assert.Equal(t, events[1], written[0])
+	})
+
+	t.Run("flushes full batches independently of the request context", func(t *testing.T) {
+		var flushErr error
+		sink := audit.SinkFunc(func(ctx context.Context, events []audit.Event) error {
+			flushErr = ctx.Err()
+			return nil
+		})
+		recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithBatchSize(1), audit.WithFlushInterval(0))
+
+		ctx, cancel := context.WithCancel(newRequestContext())
+		cancel()
+
+		require.NoError(t, recorder.Record(ctx, "survey.create", "1", nil))
+		assert.NoError(t, flushErr)
+	})
+
+	t.Run("requires an action", func(t *testing.T) {
+		recorder := audit.NewRecorder(audit.WithSink(audittest.NewSink()), audit.WithFlushInterval(0))
+		assert.Error(t, recorder.Record(context.Background(), "", "target", nil))
+	})
+
+	t.Run("flushes in the background", func(t *testing.T) {
+		sink := audittest.NewSink()
+		recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithFlushInterval(10*time.Millisecond))
+		t.Cleanup(func() { _ = recorder.Close(context.Background()) })
+
+		require.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+
+		assert.Eventually(t, func() bool {
+			return len(sink.Events()) == 1
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("reports background flush errors", func(t *testing.T) {
+		sink := audittest.NewSink()
+		sink.FailWith(errors.New("sink unavailable"))
+
+		var (
+			mu   sync.Mutex
+			errs []error
+		)
+		recorder := audit.NewRecorder(
+			audit.WithSink(sink),
+			audit.WithFlushInterval(10*time.Millisecond),
+			audit.WithErrorHandler(func(err error) {
+				mu.Lock()
+				defer mu.Unlock()
+				errs = append(errs, err)
+			}))
+		t.Cleanup(func() { _ = recorder.Close(context.Background()) })
+
+		require.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(errs) > 0
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("flushes on close and rejects later events", func(t *testing.T) {
+		sink := audittest.NewSink()
+		recorder := audit.NewRecorder(audit.WithSink(sink))
+
+		require.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+		require.NoError(t, recorder.Close(context.Background()))
+		assert.Len(t, sink.Events(), 1)
+
+		assert.ErrorIs(t, recorder.Record(context.Background(), "job.run", "", nil), audit.ErrRecorderClosed)
+		assert.NoError(t, recorder.Close(context.Background()))
+	})
+
+	t.Run("records concurrently", func(t *testing.T) {
+		sink := audittest.NewSink()
+		recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithBatchSize(7))
+
+		var wg sync.WaitGroup
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, recorder.Record(context.Background(), "job.run", "", nil))
+			}()
+		}
+		wg.Wait()
+
+		require.NoError(t, recorder.Close(context.Background()))
+		assert.Len(t, sink.Events(), 50)
+	})
+}
+
+func TestImpersonationAuditor(t *testing.T) {
+	sink := audittest.NewSink()
+	recorder := audit.NewRecorder(audit.WithSink(sink), audit.WithBatchSize(1))
+
+	handler := request.NewImpersonationHTTPMiddleware(
+		request.WithImpersonationAuditor(recorder.ImpersonationAuditor()),
+	)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	req := httptest.NewRequest(http.MethodDelete, "/surveys/1", nil).WithContext(newRequestContext())
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	events := sink.Events()
+	require.Len(t, events, 1)
+	assert.Equal(t, "impersonation.write", events[0].Action)
+	assert.Equal(t, "DELETE /surveys/1", events[0].Target)
+	assert.Equal(t, "789", events[0].Actor.RealUserID)
+	assert.True(t, events[0].Actor.Impersonated)
+	assert.Equal(t, "abc", events[0].RequestID)
+}
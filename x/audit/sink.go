@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// Sink writes batches of audit events to a destination. Write may be called
+// with events from several requests, and is not called concurrently by a
+// Recorder.
+type Sink interface {
+	Write(ctx context.Context, events []Event) error
+}
+
+// WriteError is returned by a Sink that could not write some of the events
+// it was given, for example because entries of a batch were throttled. The
+// Recorder retries the Failed events on its next flush. Errors of other types
+// are treated as if none of the events were written.
+type WriteError struct {
+	// Failed holds the events that were not written and can be retried.
+	Failed []Event
+	// Err describes the first failure.
+	Err error
+}
+
+// Error implements error.
+func (e *WriteError) Error() string {
+	return fmt.Sprintf("%d audit events not written: %v", len(e.Failed), e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *WriteError) Unwrap() error {
+	return e.Err
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, events []Event) error
+
+// Write implements Sink.
+func (f SinkFunc) Write(ctx context.Context, events []Event) error {
+	return f(ctx, events)
+}
+
+// JSONSink writes each event as a line of JSON.
+type JSONSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewJSONSink returns a Sink that writes events to w as JSON lines.
+func NewJSONSink(w io.Writer) *JSONSink {
+	return &JSONSink{w: w}
+}
+
+// NewStdoutSink returns a Sink that writes events to standard output as JSON
+// lines, for collection with the rest of the service's logs.
+func NewStdoutSink() *JSONSink {
+	return NewJSONSink(os.Stdout)
+}
+
+// Write implements Sink. Events that can't be encoded are skipped and are
+// not retried. If writing fails, a *WriteError listing the event that failed
+// and the events after it is returned.
+func (s *JSONSink) Write(ctx context.Context, events []Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures := &writeFailures{}
+
+	for i, event := range events {
+		line, err := json.Marshal(event)
+		if err != nil {
+			// Encoding fails on every attempt, so the event isn't retried.
+			failures.add(fmt.Errorf("encode audit event %s: %w", event.ID, err))
+			continue
+		}
+
+		if _, err := s.w.Write(append(line, '\n')); err != nil {
+			failures.add(fmt.Errorf("write audit event %s: %w", event.ID, err), events[i:]...)
+			break
+		}
+	}
+
+	return failures.err()
+}
+
+// FileSink is a JSONSink that appends to a file. It is closed by
+// Recorder.Close.
+type FileSink struct {
+	*JSONSink
+	file *os.File
+}
+
+// OpenFileSink opens the file at path for appending, creating it if
+// necessary, and returns a Sink that writes events to it as JSON lines.
+func OpenFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open audit file: %w", err)
+	}
+
+	return &FileSink{JSONSink: NewJSONSink(file), file: file}, nil
+}
+
+// Write implements Sink. The file is synced after each batch, so that
+// recorded events survive a crash.
+func (s *FileSink) Write(ctx context.Context, events []Event) error {
+	writeErr := s.JSONSink.Write(ctx, events)
+
+	if err := s.file.Sync(); err != nil {
+		return fmt.Errorf("sync audit file: %w", err)
+	}
+
+	return writeErr
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
+
+// writeFailures collects the events that a sink failed to write, and the
+// first error, across batches.
+type writeFailures struct {
+	failed   []Event
+	firstErr error
+}
+
+func (f *writeFailures) add(err error, failed ...Event) {
+	if f.firstErr == nil {
+		f.firstErr = err
+	}
+	f.failed = append(f.failed, failed...)
+}
+
+func (f *writeFailures) err() error {
+	if f.firstErr == nil {
+		return nil
+	}
+
+	return &WriteError{Failed: f.failed, Err: f.firstErr}
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go/aws"
+	awsrequest "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// Batch size limits of the SQS SendMessageBatch and Kinesis PutRecords APIs.
+const (
+	sqsMaxBatchSize     = 10
+	kinesisMaxBatchSize = 500
+)
+
+// SQSAPI is the subset of the SQS client used by SQSSink. It is satisfied by
+// *sqs.SQS and sqsiface.SQSAPI, and by audittest.Queue in tests.
+type SQSAPI interface {
+	SendMessageBatchWithContext(ctx aws.Context, input *sqs.SendMessageBatchInput, opts ...awsrequest.Option) (*sqs.SendMessageBatchOutput, error)
+}
+
+// KinesisAPI is the subset of the Kinesis client used by KinesisSink. It is
+// satisfied by *kinesis.Kinesis and kinesisiface.KinesisAPI, and by
+// audittest.Queue in tests.
+type KinesisAPI interface {
+	PutRecordsWithContext(ctx aws.Context, input *kinesis.PutRecordsInput, opts ...awsrequest.Option) (*kinesis.PutRecordsOutput, error)
+}
+
+// SQSSink sends each event as a JSON message to an SQS queue.
+type SQSSink struct {
+	client   SQSAPI
+	queueURL string
+}
+
+// NewSQSSink returns a Sink that sends events to the SQS queue with the given
+// URL.
+func NewSQSSink(client SQSAPI, queueURL string) *SQSSink {
+	return &SQSSink{client: client, queueURL: queueURL}
+}
+
+// Write implements Sink. Events are sent in batches of up to 10 messages. If
+// any message is not accepted, the remaining batches are still sent, and a
+// *WriteError listing the events that failed is returned.
+func (s *SQSSink) Write(ctx context.Context, events []Event) error {
+	failures := &writeFailures{}
+
+	for _, batch := range chunk(events, sqsMaxBatchSize) {
+		input := &sqs.SendMessageBatchInput{QueueUrl: aws.String(s.queueURL)}
+		var sent []Event
+
+		for _, event := range batch {
+			body, err := json.Marshal(event)
+			if err != nil {
+				// Encoding fails on every attempt, so the event isn't retried.
+				failures.add(fmt.Errorf("encode audit event %s: %w", event.ID, err))
+				continue
+			}
+
+			// The entry ID is the event's index in sent, to find failed events.
+			input.Entries = append(input.Entries, &sqs.SendMessageBatchRequestEntry{
+				Id:          aws.String(strconv.Itoa(len(sent))),
+				MessageBody: aws.String(string(body)),
+			})
+			sent = append(sent, event)
+		}
+
+		if len(input.Entries) == 0 {
+			continue
+		}
+
+		output, err := s.client.SendMessageBatchWithContext(ctx, input)
+		if err != nil {
+			failures.add(fmt.Errorf("send audit events: %w", err), sent...)
+			continue
+		}
+
+		if len(output.Failed) > 0 {
+			var failed []Event
+			for _, entry := range output.Failed {
+				if i, err := strconv.Atoi(aws.StringValue(entry.Id)); err == nil && i >= 0 && i < len(sent) {
+					failed = append(failed, sent[i])
+				}
+			}
+
+			failures.add(fmt.Errorf("send audit events: %d of %d messages failed: %s",
+				len(output.Failed), len(input.Entries), aws.StringValue(output.Failed[0].Message)), failed...)
+		}
+	}
+
+	return failures.err()
+}
+
+// KinesisSink puts each event as a JSON record on a Kinesis data stream. Events
+// are partitioned by account, so that the events of an account are ordered.
+type KinesisSink struct {
+	client     KinesisAPI
+	streamName string
+}
+
+// NewKinesisSink returns a Sink that puts events on the named Kinesis data
+// stream.
+func NewKinesisSink(client KinesisAPI, streamName string) *KinesisSink {
+	return &KinesisSink{client: client, streamName: streamName}
+}
+
+// Write implements Sink. Events are put in batches of up to 500 records. If
+// any record is not accepted, the remaining batches are still put, and a
+// *WriteError listing the events that failed is returned.
+func (s *KinesisSink) Write(ctx context.Context, events []Event) error {
+	failures := &writeFailures{}
+
+	for _, batch := range chunk(events, kinesisMaxBatchSize) {
+		input := &kinesis.PutRecordsInput{StreamName: aws.String(s.streamName)}
+		var sent []Event
+
+		for _, event := range batch {
+			data, err := json.Marshal(event)
+			if err != nil {
+				// Encoding fails on every attempt, so the event isn't retried.
+				failures.add(fmt.Errorf("encode audit event %s: %w", event.ID, err))
+				continue
+			}
+
+			sent = append(sent, event)
+			input.Records = append(input.Records, &kinesis.PutRecordsRequestEntry{
+				Data:         data,
+				PartitionKey: aws.String(partitionKey(event)),
+			})
+		}
+
+		if len(input.Records) == 0 {
+			continue
+		}
+
+		output, err := s.client.PutRecordsWithContext(ctx, input)
+		if err != nil {
+			failures.add(fmt.Errorf("put audit events: %w", err), sent...)
+			continue
+		}
+
+		if failedCount := aws.Int64Value(output.FailedRecordCount); failedCount > 0 {
+			// Results are in the same order as the records in the request.
+			var failed []Event
+			for i, record := range output.Records {
+				if aws.StringValue(record.ErrorCode) != "" && i < len(sent) {
+					failed = append(failed, sent[i])
+				}
+			}
+
+			failures.add(fmt.Errorf("put audit events: %d of %d records failed", failedCount, len(input.Records)), failed...)
+		}
+	}
+
+	return failures.err()
+}
+
+// partitionKey returns the account ID of the event's actor, falling back to
+// the event ID for events without an account.
+func partitionKey(event Event) string {
+	if event.Actor.AccountID != "" {
+		return event.Actor.AccountID
+	}
+
+	return event.ID
+}
+
+func chunk[T any](items []T, size int) [][]T {
+	var chunks [][]T
+
+	for size < len(items) {
+		items, chunks = items[size:], append(chunks, items[:size:size])
+	}
+
+	if len(items) > 0 {
+		chunks = append(chunks, items)
+	}
+
+	return chunks
+}
@@ -0,0 +1,159 @@
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/audit/audittest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEvents(n int) []audit.Event {
+	events := make([]audit.Event, n)
+	for i := range events {
+		events[i] = audit.NewEvent(newRequestContext(), "survey.create", "", nil)
+	}
+
+	return events
+}
+
+func decodeLines(t *testing.T, data []byte) []audit.Event {
+	t.Helper()
+
+	var events []audit.Event
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var event audit.Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+
+	return events
+}
+
+func TestJSONSink(t *testing.T) {
+	t.Run("writes events as JSON lines", func(t *testing.T) {
+		var buf bytes.Buffer
+		events := newEvents(2)
+
+		require.NoError(t, audit.NewJSONSink(&buf).Write(context.Background(), events))
+		assert.Equal(t, events, decodeLines(t, buf.Bytes()))
+	})
+
+	t.Run("skips events that can't be encoded", func(t *testing.T) {
+		var buf bytes.Buffer
+		events := newEvents(3)
+		events[1].Details = map[string]interface{}{"score": math.NaN()}
+
+		err := audit.NewJSONSink(&buf).Write(context.Background(), events)
+		var werr *audit.WriteError
+		require.ErrorAs(t, err, &werr)
+		assert.Empty(t, werr.Failed, "events that can't be encoded are not retried")
+
+		assert.Equal(t, []audit.Event{events[0], events[2]}, decodeLines(t, buf.Bytes()))
+	})
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := audit.OpenFileSink(path)
+	require.NoError(t, err)
+
+	recorder := audit.NewRecorder(audit.WithSink(sink))
+	require.NoError(t, recorder.Record(newRequestContext(), "survey.delete", "1", nil))
+	require.NoError(t, recorder.Close(context.Background()))
+
+	// Events are appended to an existing file.
+	sink, err = audit.OpenFileSink(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Write(context.Background(), newEvents(1)))
+	require.NoError(t, sink.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	events := decodeLines(t, data)
+	require.Len(t, events, 2)
+	assert.Equal(t, "survey.delete", events[0].Action)
+	assert.Equal(t, "survey.create", events[1].Action)
+}
+
+func TestSQSSink(t *testing.T) {
+	t.Run("sends events in batches of 10", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		events := newEvents(23)
+
+		require.NoError(t, audit.NewSQSSink(queue, "queue-url").Write(context.Background(), events))
+		assert.Equal(t, 3, queue.Calls())
+
+		sent, err := queue.Events()
+		require.NoError(t, err)
+		assert.Equal(t, events, sent)
+	})
+
+	t.Run("returns an error if a message fails", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		queue.FailEntry(1)
+
+		err := audit.NewSQSSink(queue, "queue-url").Write(context.Background(), newEvents(2))
+		assert.ErrorContains(t, err, "1 of 2 messages failed")
+	})
+
+	t.Run("sends the remaining batches and lists the failed events", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		queue.FailEntry(1)
+		events := newEvents(12)
+
+		err := audit.NewSQSSink(queue, "queue-url").Write(context.Background(), events)
+
+		var werr *audit.WriteError
+		require.ErrorAs(t, err, &werr)
+		assert.Equal(t, []audit.Event{events[1], events[11]}, werr.Failed)
+		assert.Equal(t, 2, queue.Calls())
+	})
+}
+
+func TestKinesisSink(t *testing.T) {
+	t.Run("puts events in batches of 500", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		events := newEvents(501)
+
+		require.NoError(t, audit.NewKinesisSink(queue, "stream").Write(context.Background(), events))
+		assert.Equal(t, 2, queue.Calls())
+
+		put, err := queue.Events()
+		require.NoError(t, err)
+		assert.Equal(t, events, put)
+	})
+
+	t.Run("partitions events without an account", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		event := audit.NewEvent(context.Background(), "job.run", "", nil)
+
+		require.NoError(t, audit.NewKinesisSink(queue, "stream").Write(context.Background(), []audit.Event{event}))
+	})
+
+	t.Run("returns an error if a record fails", func(t *testing.T) {
+		queue := audittest.NewQueue()
+		queue.FailEntry(0)
+
+		events := newEvents(3)
+
+		err := audit.NewKinesisSink(queue, "stream").Write(context.Background(), events)
+		assert.ErrorContains(t, err, "1 of 3 records failed")
+
+		var werr *audit.WriteError
+		require.ErrorAs(t, err, &werr)
+		assert.Equal(t, []audit.Event{events[0]}, werr.Failed)
+	})
+}
@@ -0,0 +1,58 @@
+// Package sentrytest provides a Sentry transport for the tests of packages
+// that report errors with errorreport.
+package sentrytest
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cultureamp/ca-go/x/sentry/errorreport"
+	"github.com/getsentry/sentry-go"
+)
+
+// Init initialises errorreport with a new Transport, so that reported events
+// are kept in memory rather than sent to Sentry, and returns the Transport.
+func Init(t testing.TB) *Transport {
+	t.Helper()
+
+	transport := &Transport{}
+	err := errorreport.Init(
+		errorreport.WithEnvironment("test"),
+		errorreport.WithDSN("https://public@sentry.example.com/1"),
+		errorreport.WithRelease("my-app", "1.0.0"),
+		errorreport.WithTransport(transport),
+	)
+	if err != nil {
+		t.Fatalf("initialise errorreport: %v", err)
+	}
+
+	return transport
+}
+
+// Transport is a sentry.Transport that keeps events in memory.
+//
+// From https://github.com/getsentry/sentry-go/blob/bd116d6ce79b604297c6497aa07d7ac01768adbb/mocks_test.go#L24-L44
+type Transport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *Transport) Configure(options sentry.ClientOptions) {}
+
+func (t *Transport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *Transport) Flush(timeout time.Duration) bool {
+	return true
+}
+
+// Events returns the events sent to the transport, in order.
+func (t *Transport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.events
+}
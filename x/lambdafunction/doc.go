@@ -2,4 +2,18 @@
 // function handlers. These definitions are used within the library, but may be
 // useful in other contexts.
 // Refer to "valid handler signatures" section at https://docs.aws.amazon.com/lambda/latest/dg/golang-handler.html
+//
+// Middleware and MiddlewareWithOutput wrap handlers, and are composed with
+// Chain and ChainWithOutput. The first middleware is the outermost:
+//   handler := lambdafunction.Chain(
+//     errorreport.NewLambdaMiddleware[events.SQSEvent](),
+//     teamMiddleware,
+//   )(processMessages)
+//
+// Handlers are adapted between HandlerOf and HandlerWithOutputOf with
+// DiscardOutput and ZeroOutput, and MiddlewareForOutput allows a Middleware to
+// wrap handlers with output.
+//
+// The sqsbatch package provides a handler for SQS events that processes each
+// record separately and reports partial batch failures.
 package lambdafunction
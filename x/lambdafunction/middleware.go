@@ -0,0 +1,78 @@
+package lambdafunction
+
+import (
+	"context"
+)
+
+// Middleware[TIn] wraps a HandlerOf[TIn] to add behaviour before or after the
+// handler is invoked.
+type Middleware[TIn any] func(HandlerOf[TIn]) HandlerOf[TIn]
+
+// MiddlewareWithOutput[TIn, TOut] wraps a HandlerWithOutputOf[TIn, TOut] to
+// add behaviour before or after the handler is invoked.
+type MiddlewareWithOutput[TIn any, TOut any] func(HandlerWithOutputOf[TIn, TOut]) HandlerWithOutputOf[TIn, TOut]
+
+// Chain[TIn] composes the middleware into a single Middleware. The first
+// middleware is the outermost, so it is invoked first and returns last.
+func Chain[TIn any](middleware ...Middleware[TIn]) Middleware[TIn] {
+	return func(next HandlerOf[TIn]) HandlerOf[TIn] {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+
+		return next
+	}
+}
+
+// ChainWithOutput[TIn, TOut] composes the middleware into a single
+// MiddlewareWithOutput, in the same order as Chain.
+func ChainWithOutput[TIn any, TOut any](middleware ...MiddlewareWithOutput[TIn, TOut]) MiddlewareWithOutput[TIn, TOut] {
+	return func(next HandlerWithOutputOf[TIn, TOut]) HandlerWithOutputOf[TIn, TOut] {
+		for i := len(middleware) - 1; i >= 0; i-- {
+			next = middleware[i](next)
+		}
+
+		return next
+	}
+}
+
+// DiscardOutput[TIn, TOut] adapts a HandlerWithOutputOf[TIn, TOut] to a
+// HandlerOf[TIn] by discarding its output.
+func DiscardOutput[TIn any, TOut any](handler HandlerWithOutputOf[TIn, TOut]) HandlerOf[TIn] {
+	return func(ctx context.Context, event TIn) error {
+		_, err := handler(ctx, event)
+		return err
+	}
+}
+
+// ZeroOutput[TIn, TOut] adapts a HandlerOf[TIn] to a
+// HandlerWithOutputOf[TIn, TOut] that returns the zero value of TOut.
+func ZeroOutput[TIn any, TOut any](handler HandlerOf[TIn]) HandlerWithOutputOf[TIn, TOut] {
+	return func(ctx context.Context, event TIn) (TOut, error) {
+		var out TOut
+		return out, handler(ctx, event)
+	}
+}
+
+// MiddlewareForOutput[TIn, TOut] adapts a Middleware[TIn] so that it can wrap
+// handlers with output, so middleware that doesn't use the output only needs
+// to be written once. The output of the wrapped handler is returned
+// unchanged. The middleware is applied on each invocation, so it should be
+// cheap to apply.
+func MiddlewareForOutput[TIn any, TOut any](middleware Middleware[TIn]) MiddlewareWithOutput[TIn, TOut] {
+	return func(next HandlerWithOutputOf[TIn, TOut]) HandlerWithOutputOf[TIn, TOut] {
+		return func(ctx context.Context, event TIn) (TOut, error) {
+			var out TOut
+
+			handler := middleware(func(ctx context.Context, event TIn) error {
+				var err error
+				out, err = next(ctx, event)
+				return err
+			})
+
+			err := handler(ctx, event)
+
+			return out, err
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package lambdafunction_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/lambdafunction"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recording returns middleware that appends its name to calls before and
+// after invoking the handler.
+func recording(name string, calls *[]string) lambdafunction.Middleware[string] {
+	return func(next lambdafunction.HandlerOf[string]) lambdafunction.HandlerOf[string] {
+		return func(ctx context.Context, event string) error {
+			*calls = append(*calls, "before "+name)
+			err := next(ctx, event)
+			*calls = append(*calls, "after "+name)
+			return err
+		}
+	}
+}
+
+func recordingWithOutput(name string, calls *[]string) lambdafunction.MiddlewareWithOutput[string, int] {
+	return func(next lambdafunction.HandlerWithOutputOf[string, int]) lambdafunction.HandlerWithOutputOf[string, int] {
+		return func(ctx context.Context, event string) (int, error) {
+			*calls = append(*calls, "before "+name)
+			out, err := next(ctx, event)
+			*calls = append(*calls, "after "+name)
+			return out, err
+		}
+	}
+}
+
+func TestChain(t *testing.T) {
+	t.Run("applies the first middleware outermost", func(t *testing.T) {
+		var calls []string
+		handler := lambdafunction.Chain(recording("a", &calls), recording("b", &calls))(
+			func(ctx context.Context, event string) error {
+				calls = append(calls, "handler "+event)
+				return nil
+			})
+
+		require.NoError(t, handler(context.Background(), "event"))
+		assert.Equal(t, []string{"before a", "before b", "handler event", "after b", "after a"}, calls)
+	})
+
+	t.Run("returns the handler without middleware", func(t *testing.T) {
+		expected := errors.New("failed")
+		handler := lambdafunction.Chain[string]()(func(ctx context.Context, event string) error {
+			return expected
+		})
+
+		assert.Equal(t, expected, handler(context.Background(), "event"))
+	})
+}
+
+func TestChainWithOutput(t *testing.T) {
+	var calls []string
+	handler := lambdafunction.ChainWithOutput(recordingWithOutput("a", &calls), recordingWithOutput("b", &calls))(
+		func(ctx context.Context, event string) (int, error) {
+			calls = append(calls, "handler "+event)
+			return len(event), nil
+		})
+
+	out, err := handler(context.Background(), "event")
+	require.NoError(t, err)
+	assert.Equal(t, 5, out)
+	assert.Equal(t, []string{"before a", "before b", "handler event", "after b", "after a"}, calls)
+}
+
+func TestDiscardOutput(t *testing.T) {
+	expected := errors.New("failed")
+	handler := lambdafunction.DiscardOutput(func(ctx context.Context, event string) (int, error) {
+		return 1, expected
+	})
+
+	assert.Equal(t, expected, handler(context.Background(), "event"))
+}
+
+func TestZeroOutput(t *testing.T) {
+	expected := errors.New("failed")
+	handler := lambdafunction.ZeroOutput[string, int](func(ctx context.Context, event string) error {
+		return expected
+	})
+
+	out, err := handler(context.Background(), "event")
+	assert.Equal(t, expected, err)
+	assert.Zero(t, out)
+}
+
+func TestMiddlewareForOutput(t *testing.T) {
+	var calls []string
+	handler := lambdafunction.MiddlewareForOutput[string, int](recording("a", &calls))(
+		func(ctx context.Context, event string) (int, error) {
+			calls = append(calls, "handler "+event)
+			return len(event), nil
+		})
+
+	out, err := handler(context.Background(), "event")
+	require.NoError(t, err)
+	assert.Equal(t, 5, out)
+	assert.Equal(t, []string{"before a", "handler event", "after a"}, calls)
+}
@@ -0,0 +1,19 @@
+// Package sqsbatch processes the records of SQS events one at a time, so that
+// one bad message doesn't fail the whole batch.
+//
+// NewHandler decodes the body of each record and calls a RecordHandler for it.
+// Failed records are reported to Sentry and returned as BatchItemFailures, so
+// only they are retried:
+//   handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body SurveyCreated) error {
+//     return createSurvey(ctx, body)
+//   }, sqsbatch.WithConcurrency(10), sqsbatch.WithRequestExtraction())
+//
+//   lambda.Start(errorreport.LambdaWithOutputMiddleware(handler))
+//
+// The event source mapping must have ReportBatchItemFailures enabled, as
+// failed records are only returned in the response.
+//
+// For FIFO queues, use WithFIFO so that the records of each message group are
+// processed in order, and the records after a failed record in its group also
+// fail, to be retried after it.
+package sqsbatch
@@ -0,0 +1,263 @@
+package sqsbatch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"sync"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cultureamp/ca-go/x/lambdafunction"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/cultureamp/ca-go/x/sentry/errorreport"
+)
+
+// RecordHandler[T] processes a single SQS message, whose body has been
+// decoded into T.
+type RecordHandler[T any] func(ctx context.Context, record events.SQSMessage, body T) error
+
+// Decoder decodes the body of an SQS message into v, which is a pointer to
+// the body type of the RecordHandler.
+type Decoder func(body string, v interface{}) error
+
+// JSONDecoder decodes message bodies as JSON. It is the default Decoder.
+func JSONDecoder(body string, v interface{}) error {
+	return json.Unmarshal([]byte(body), v)
+}
+
+type config struct {
+	decoder        Decoder
+	concurrency    int
+	fifo           bool
+	extractRequest bool
+	extractOptions []request.ExtractOption
+}
+
+// Option is a function type that can be provided to NewHandler to configure
+// how records are processed.
+type Option func(c *config)
+
+// WithDecoder configures how message bodies are decoded. If not provided, or
+// nil, JSONDecoder is used.
+func WithDecoder(decoder Decoder) Option {
+	return func(c *config) {
+		c.decoder = decoder
+	}
+}
+
+// WithConcurrency configures the maximum number of records processed at the
+// same time. Defaults to 1, so records are processed in order.
+func WithConcurrency(limit int) Option {
+	return func(c *config) {
+		c.concurrency = limit
+	}
+}
+
+// WithFIFO configures the handler for a FIFO queue. The records of each
+// message group are processed in order, one at a time, and once a record
+// fails, the records after it in the same message group fail without being
+// processed, so that they are retried in order. Records in different message
+// groups are processed concurrently, up to the WithConcurrency limit.
+func WithFIFO() Option {
+	return func(c *config) {
+		c.fifo = true
+	}
+}
+
+// WithRequestExtraction adds the RequestIDs and AuthenticatedUser in the
+// message attributes of each record to the context passed to the
+// RecordHandler, as read by request.Extract. As with request.Extract, the
+// attributes are trusted, so only use it for queues written to by internal
+// services.
+func WithRequestExtraction(opts ...request.ExtractOption) Option {
+	return func(c *config) {
+		c.extractRequest = true
+		c.extractOptions = opts
+	}
+}
+
+// NewHandler[T] returns a Lambda handler for SQS events that decodes the body
+// of each record into T and processes it with the RecordHandler. A record
+// fails if its body can't be decoded, or the RecordHandler returns an error or
+// panics. Each failure is reported to Sentry, tagged with the message ID, and
+// the failed records are returned in the BatchItemFailures of the response.
+// Records not started before the context is done also fail, but are not
+// reported.
+//
+// Unless WithFIFO is used, records are processed independently of each
+// other, which breaks the ordering of FIFO queues when a record fails.
+//
+// The handler only returns failures in its response, so the event source
+// mapping must have ReportBatchItemFailures enabled. Otherwise, failed records
+// are deleted from the queue.
+func NewHandler[T any](handler RecordHandler[T], opts ...Option) lambdafunction.HandlerWithOutputOf[events.SQSEvent, events.SQSEventResponse] {
+	cfg := &config{
+		decoder:     JSONDecoder,
+		concurrency: 1,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if cfg.decoder == nil {
+		cfg.decoder = JSONDecoder
+	}
+
+	if cfg.concurrency < 1 {
+		cfg.concurrency = 1
+	}
+
+	b := &batch[T]{config: cfg, handler: handler}
+
+	return b.handle
+}
+
+type batch[T any] struct {
+	*config
+	handler RecordHandler[T]
+}
+
+func (b *batch[T]) handle(ctx context.Context, event events.SQSEvent) (events.SQSEventResponse, error) {
+	failed := make([]bool, len(event.Records))
+
+	var wg sync.WaitGroup
+	limit := make(chan struct{}, b.concurrency)
+
+	for _, lane := range b.lanes(event.Records) {
+		limit <- struct{}{}
+
+		wg.Add(1)
+		go func(lane []int) {
+			defer wg.Done()
+			defer func() { <-limit }()
+
+			for n, i := range lane {
+				if ctx.Err() != nil {
+					failed[i] = true
+					continue
+				}
+
+				if failed[i] = !b.process(ctx, event.Records[i]); failed[i] {
+					for _, j := range lane[n+1:] {
+						failed[j] = true
+					}
+					return
+				}
+			}
+		}(lane)
+	}
+
+	wg.Wait()
+
+	response := events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+	for i, record := range event.Records {
+		if failed[i] {
+			response.BatchItemFailures = append(response.BatchItemFailures, events.SQSBatchItemFailure{
+				ItemIdentifier: record.MessageId,
+			})
+		}
+	}
+
+	return response, nil
+}
+
+// lanes returns the indexes of the records grouped into lanes, whose records
+// are processed in order, one at a time. In FIFO mode, each message group is
+// a lane; otherwise, each record is.
+func (b *batch[T]) lanes(records []events.SQSMessage) [][]int {
+	var lanes [][]int
+	groups := map[string]int{}
+
+	for i, record := range records {
+		if group, ok := record.Attributes["MessageGroupId"]; ok && b.fifo {
+			if lane, ok := groups[group]; ok {
+				lanes[lane] = append(lanes[lane], i)
+				continue
+			}
+
+			groups[group] = len(lanes)
+		}
+
+		lanes = append(lanes, []int{i})
+	}
+
+	return lanes
+}
+
+// process processes the record, reporting any error, and returns whether it
+// succeeded.
+func (b *batch[T]) process(ctx context.Context, record events.SQSMessage) bool {
+	if b.extractRequest {
+		ctx = request.Extract(ctx, request.SQSEventMessageAttributeCarrier(record.MessageAttributes), b.extractOptions...)
+	}
+
+	if err := b.run(ctx, record); err != nil {
+		b.report(ctx, record, err)
+		return false
+	}
+
+	return true
+}
+
+func (b *batch[T]) run(ctx context.Context, record events.SQSMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = newPanicError(record.MessageId, r)
+		}
+	}()
+
+	var body T
+	if err := b.decoder(record.Body, &body); err != nil {
+		return fmt.Errorf("decode message %s: %w", record.MessageId, err)
+	}
+
+	return b.handler(ctx, record, body)
+}
+
+// panicError is the error for a panic while processing a message. It wraps
+// the recovered value if that is an error, and has the stack trace of the
+// panic, which Sentry reads from its StackTrace method.
+type panicError struct {
+	messageID string
+	value     interface{}
+	stack     []uintptr
+}
+
+// newPanicError returns a panicError for the recovered value. It must be
+// called directly by the deferred function that recovered it, so that the
+// stack trace starts at the panic.
+func newPanicError(messageID string, value interface{}) *panicError {
+	stack := make([]uintptr, 64)
+	// Skip runtime.Callers, newPanicError, the deferred function and
+	// runtime.gopanic.
+	n := runtime.Callers(4, stack)
+
+	return &panicError{
+		messageID: messageID,
+		value:     value,
+		stack:     stack[:n],
+	}
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic processing message %s: %v", e.messageID, e.value)
+}
+
+func (e *panicError) Unwrap() error {
+	err, _ := e.value.(error)
+	return err
+}
+
+// StackTrace returns the program counters of the stack at the panic.
+func (e *panicError) StackTrace() []uintptr {
+	return e.stack
+}
+
+func (b *batch[T]) report(ctx context.Context, record events.SQSMessage, err error) {
+	errorreport.ReportErrorWithTags(ctx, err, map[string]string{
+		"message_id": record.MessageId,
+	})
+}
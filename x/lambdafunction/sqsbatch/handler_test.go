@@ -0,0 +1,272 @@
+package sqsbatch_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/cultureamp/ca-go/x/internal/sentrytest"
+	"github.com/cultureamp/ca-go/x/lambdafunction/sqsbatch"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	Action string `json:"action"`
+}
+
+func newEvent(bodies ...string) events.SQSEvent {
+	var event events.SQSEvent
+	for i, body := range bodies {
+		event.Records = append(event.Records, events.SQSMessage{
+			MessageId: fmt.Sprintf("msg-%d", i),
+			Body:      body,
+		})
+	}
+
+	return event
+}
+
+func failedIDs(response events.SQSEventResponse) []string {
+	ids := []string{}
+	for _, failure := range response.BatchItemFailures {
+		ids = append(ids, failure.ItemIdentifier)
+	}
+
+	return ids
+}
+
+func process(ctx context.Context, record events.SQSMessage, body message) error {
+	switch body.Action {
+	case "fail":
+		return errors.New("failed")
+	case "panic":
+		panic("boom")
+	default:
+		return nil
+	}
+}
+
+var errBoom = errors.New("boom")
+
+func panicWithError(ctx context.Context, record events.SQSMessage, body message) error {
+	panic(errBoom)
+}
+
+func newFIFOEvent(groups ...string) events.SQSEvent {
+	var event events.SQSEvent
+	for i, group := range groups {
+		event.Records = append(event.Records, events.SQSMessage{
+			MessageId:  fmt.Sprintf("msg-%d", i),
+			Body:       `{}`,
+			Attributes: map[string]string{"MessageGroupId": group},
+		})
+	}
+
+	return event
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Run("returns failed records", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		handler := sqsbatch.NewHandler(process)
+
+		response, err := handler(context.Background(), newEvent(
+			`{"action":"ok"}`,
+			`{"action":"fail"}`,
+			`not json`,
+			`{"action":"panic"}`,
+			`{"action":"ok"}`,
+		))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"msg-1", "msg-2", "msg-3"}, failedIDs(response))
+
+		require.Len(t, mockSentryTransport.Events(), 3)
+		for i, event := range mockSentryTransport.Events() {
+			assert.Equal(t, fmt.Sprintf("msg-%d", i+1), event.Tags["message_id"])
+		}
+	})
+
+	t.Run("returns no failures", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		handler := sqsbatch.NewHandler(process)
+
+		response, err := handler(context.Background(), newEvent(`{"action":"ok"}`))
+		require.NoError(t, err)
+
+		assert.Empty(t, response.BatchItemFailures)
+		assert.Empty(t, mockSentryTransport.Events())
+	})
+
+	t.Run("limits concurrency", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+
+		var running, maxRunning int32
+		handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body message) error {
+			n := atomic.AddInt32(&running, 1)
+			defer atomic.AddInt32(&running, -1)
+
+			for {
+				current := atomic.LoadInt32(&maxRunning)
+				if n <= current || atomic.CompareAndSwapInt32(&maxRunning, current, n) {
+					break
+				}
+			}
+
+			time.Sleep(10 * time.Millisecond)
+			return process(ctx, record, body)
+		}, sqsbatch.WithConcurrency(3))
+
+		bodies := make([]string, 10)
+		for i := range bodies {
+			bodies[i] = `{"action":"ok"}`
+		}
+		bodies[4] = `{"action":"fail"}`
+
+		response, err := handler(context.Background(), newEvent(bodies...))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"msg-4"}, failedIDs(response))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&maxRunning))
+		require.Len(t, mockSentryTransport.Events(), 1)
+		assert.Equal(t, "msg-4", mockSentryTransport.Events()[0].Tags["message_id"])
+	})
+
+	t.Run("fails records not started before the context is done", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		handler := sqsbatch.NewHandler(func(_ context.Context, record events.SQSMessage, body message) error {
+			cancel()
+			return nil
+		})
+
+		response, err := handler(ctx, newEvent(`{}`, `{}`, `{}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"msg-1", "msg-2"}, failedIDs(response))
+		assert.Empty(t, mockSentryTransport.Events())
+	})
+
+	t.Run("reports panics with the error and stack trace", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		handler := sqsbatch.NewHandler(panicWithError)
+
+		response, err := handler(context.Background(), newEvent(`{}`))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"msg-0"}, failedIDs(response))
+		require.Len(t, mockSentryTransport.Events(), 1)
+
+		exceptions := mockSentryTransport.Events()[0].Exception
+		require.Len(t, exceptions, 2)
+		assert.Equal(t, errBoom.Error(), exceptions[0].Value)
+		assert.Equal(t, "panic processing message msg-0: boom", exceptions[1].Value)
+
+		require.NotNil(t, exceptions[1].Stacktrace)
+		frames := exceptions[1].Stacktrace.Frames
+		require.NotEmpty(t, frames)
+		assert.Equal(t, "panicWithError", frames[len(frames)-1].Function)
+	})
+
+	t.Run("fails later records of a failed FIFO message group", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+
+		var mu sync.Mutex
+		var processed []string
+		handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body message) error {
+			mu.Lock()
+			processed = append(processed, record.MessageId)
+			mu.Unlock()
+
+			if record.MessageId == "msg-1" {
+				return errors.New("failed")
+			}
+			return nil
+		}, sqsbatch.WithFIFO(), sqsbatch.WithConcurrency(2))
+
+		response, err := handler(context.Background(), newFIFOEvent("a", "a", "b", "a", "b"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"msg-1", "msg-3"}, failedIDs(response))
+		assert.ElementsMatch(t, []string{"msg-0", "msg-1", "msg-2", "msg-4"}, processed)
+		require.Len(t, mockSentryTransport.Events(), 1)
+		assert.Equal(t, "msg-1", mockSentryTransport.Events()[0].Tags["message_id"])
+	})
+
+	t.Run("processes FIFO message groups in order", func(t *testing.T) {
+		sentrytest.Init(t)
+
+		var mu sync.Mutex
+		processed := map[string][]string{}
+		handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body message) error {
+			time.Sleep(time.Millisecond)
+
+			group := record.Attributes["MessageGroupId"]
+			mu.Lock()
+			processed[group] = append(processed[group], record.MessageId)
+			mu.Unlock()
+			return nil
+		}, sqsbatch.WithFIFO(), sqsbatch.WithConcurrency(2))
+
+		response, err := handler(context.Background(), newFIFOEvent("a", "b", "a", "b", "a"))
+		require.NoError(t, err)
+
+		assert.Empty(t, failedIDs(response))
+		assert.Equal(t, map[string][]string{
+			"a": {"msg-0", "msg-2", "msg-4"},
+			"b": {"msg-1", "msg-3"},
+		}, processed)
+	})
+
+	t.Run("uses the decoder", func(t *testing.T) {
+		sentrytest.Init(t)
+
+		var decoded []string
+		handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body string) error {
+			decoded = append(decoded, body)
+			return nil
+		}, sqsbatch.WithDecoder(func(body string, v interface{}) error {
+			*v.(*string) = body
+			return nil
+		}))
+
+		_, err := handler(context.Background(), newEvent("plain text"))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"plain text"}, decoded)
+	})
+
+	t.Run("extracts request attributes", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+
+		event := newEvent(`{"action":"fail"}`)
+		event.Records[0].MessageAttributes = map[string]events.SQSMessageAttribute{
+			request.RequestIDKey: {DataType: "String", StringValue: stringPtr("abc")},
+		}
+
+		var ids request.RequestIDs
+		handler := sqsbatch.NewHandler(func(ctx context.Context, record events.SQSMessage, body message) error {
+			ids, _ = request.RequestIDsFromContext(ctx)
+			return process(ctx, record, body)
+		}, sqsbatch.WithRequestExtraction())
+
+		_, err := handler(context.Background(), event)
+		require.NoError(t, err)
+
+		assert.Equal(t, "abc", ids.RequestID)
+		require.Len(t, mockSentryTransport.Events(), 1)
+		assert.Equal(t, "abc", mockSentryTransport.Events()[0].Tags["RequestID"])
+	})
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
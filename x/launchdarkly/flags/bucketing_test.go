@@ -0,0 +1,132 @@
+package flags
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+// ldUserContext adapts an lduser.User to an evaluationcontext.Context, for
+// exercising attributes that evaluationcontext.User doesn't set.
+type ldUserContext struct {
+	user lduser.User
+}
+
+func (c ldUserContext) ToLDUser() lduser.User {
+	return c.user
+}
+
+func TestRolloutBucket(t *testing.T) {
+	// Expected bucket values are taken from the LaunchDarkly evaluation
+	// library's own tests.
+	rollout := Rollout{Flag: "hashKey", Salt: "saltyA"}
+
+	t.Run("buckets by key", func(t *testing.T) {
+		assert.InEpsilon(t, 0.42157587, rollout.Bucket(evaluationcontext.NewUser("userKeyA")), 0.0000001)
+		assert.InEpsilon(t, 0.6708485, rollout.Bucket(evaluationcontext.NewUser("userKeyB")), 0.0000001)
+		assert.InEpsilon(t, 0.10343106, rollout.Bucket(evaluationcontext.NewAnonymousUser("userKeyC")), 0.0000001)
+		assert.InEpsilon(t, 42.157587, rollout.Percentage(evaluationcontext.NewUser("userKeyA")), 0.0000001)
+	})
+
+	t.Run("buckets with a seed", func(t *testing.T) {
+		seed := 61
+		seeded := Rollout{Flag: "otherHashKey", Salt: "otherSalt", Seed: &seed}
+
+		assert.InEpsilon(t, 0.09801207, seeded.Bucket(evaluationcontext.NewUser("userKeyA")), 0.0000001)
+		assert.InEpsilon(t, 0.14483777, seeded.Bucket(evaluationcontext.NewUser("userKeyB")), 0.0000001)
+		assert.InEpsilon(t, 0.9242641, seeded.Bucket(evaluationcontext.NewUser("userKeyC")), 0.0000001)
+	})
+
+	t.Run("buckets by a custom attribute", func(t *testing.T) {
+		byAttr := Rollout{Flag: "hashKey", Salt: "saltyA", BucketBy: "intAttr"}
+
+		intUser := ldUserContext{lduser.NewUserBuilder("userKeyD").Custom("intAttr", ldvalue.Int(33333)).Build()}
+		assert.InEpsilon(t, 0.54771423, byAttr.Bucket(intUser), 0.0000001)
+
+		stringUser := ldUserContext{lduser.NewUserBuilder("userKeyD").Custom("intAttr", ldvalue.String("33333")).Build()}
+		assert.InEpsilon(t, 0.54771423, byAttr.Bucket(stringUser), 0.0000001)
+
+		floatUser := ldUserContext{lduser.NewUserBuilder("userKeyE").Custom("intAttr", ldvalue.Float64(999.999)).Build()}
+		assert.Zero(t, byAttr.Bucket(floatUser))
+
+		assert.Zero(t, byAttr.Bucket(evaluationcontext.NewUser("userKeyD")))
+	})
+
+	t.Run("includes the secondary key", func(t *testing.T) {
+		primary := ldUserContext{lduser.NewUser("userKey")}
+		secondary := ldUserContext{lduser.NewUserBuilder("userKey").Secondary("mySecondaryKey").Build()}
+		assert.NotEqual(t, rollout.Bucket(primary), rollout.Bucket(secondary))
+	})
+
+	t.Run("ignores the secondary key with a seed", func(t *testing.T) {
+		seed := 61
+		seeded := Rollout{Flag: "otherHashKey", Salt: "otherSalt", Seed: &seed}
+
+		secondary := ldUserContext{lduser.NewUserBuilder("userKeyA").Secondary("mySecondaryKey").Build()}
+		assert.InEpsilon(t, 0.09801207, seeded.Bucket(secondary), 0.0000001)
+	})
+}
+
+func TestRolloutVariation(t *testing.T) {
+	rollout := Rollout{Flag: "hashKey", Salt: "saltyA"}
+
+	assert.Equal(t, 0, rollout.Variation(evaluationcontext.NewUser("userKeyA"), 60000, 40000))
+	assert.Equal(t, 1, rollout.Variation(evaluationcontext.NewUser("userKeyB"), 60000, 40000))
+	assert.Equal(t, 0, rollout.Variation(evaluationcontext.NewUser("userKeyC"), 60000, 40000))
+
+	// Contexts beyond the total weight fall into the last variation.
+	assert.Equal(t, 1, rollout.Variation(evaluationcontext.NewUser("userKeyB"), 10000, 10000))
+	assert.Equal(t, -1, rollout.Variation(evaluationcontext.NewUser("userKeyB")))
+
+	assert.True(t, rollout.Includes(evaluationcontext.NewUser("userKeyA"), 60))
+	assert.False(t, rollout.Includes(evaluationcontext.NewUser("userKeyB"), 60))
+	assert.False(t, rollout.Includes(evaluationcontext.NewUser("userKeyC"), 0))
+	assert.True(t, rollout.Includes(evaluationcontext.NewUser("userKeyC"), 100))
+}
+
+func TestRolloutMatchesLaunchDarkly(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "flags.json")
+	require.NoError(t, os.WriteFile(filename, []byte(`{
+		"flags": {
+			"rollout-flag": {
+				"key": "rollout-flag",
+				"on": true,
+				"salt": "c0ffee",
+				"variations": ["a", "b", "c"],
+				"fallthrough": {
+					"rollout": {
+						"variations": [
+							{"variation": 0, "weight": 33333},
+							{"variation": 1, "weight": 33333},
+							{"variation": 2, "weight": 33334}
+						]
+					}
+				},
+				"version": 1
+			}
+		}
+	}`), 0o600))
+
+	c, err := NewClient(WithTestMode(&TestModeConfig{FlagFilename: filename, IgnoreDefaultFlagsFile: true}))
+	require.NoError(t, err)
+	require.NoError(t, c.Connect())
+	t.Cleanup(func() { _ = c.Shutdown() })
+
+	rollout := Rollout{Flag: "rollout-flag", Salt: "c0ffee"}
+	variations := []string{"a", "b", "c"}
+
+	for i := 0; i < 200; i++ {
+		user := evaluationcontext.NewUser(fmt.Sprintf("user-%d", i))
+
+		expected, err := c.QueryStringWithEvaluationContext("rollout-flag", user, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, expected, variations[rollout.Variation(user, 33333, 33333, 33334)], "user-%d", i)
+	}
+}
@@ -8,12 +8,16 @@ import (
 	"time"
 
 	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
 	ld "gopkg.in/launchdarkly/go-server-sdk.v5"
 	"gopkg.in/launchdarkly/go-server-sdk.v5/testhelpers/ldtestdata"
 )
 
 // Client is a wrapper around the LaunchDarkly client.
 type Client struct {
+	name          string
 	sdkKey        string
 	initWait      time.Duration
 	mode          mode
@@ -21,6 +25,12 @@ type Client struct {
 	wrappedClient *ld.LDClient
 
 	testModeConfig *TestModeConfig
+	tracked        trackedEvents
+
+	telemetryConfig *TelemetryConfig
+	overrideConfig  *OverrideConfig
+	overrides       *overrides
+	hooks           []Hook
 
 	// Optional config overrides.
 	proxyModeConfig  *ProxyModeConfig
@@ -50,6 +60,24 @@ func NewClient(opts ...ConfigOption) (*Client, error) {
 		opt(c)
 	}
 
+	if c.overrideConfig != nil {
+		o, err := newOverrides(c.overrideConfig)
+		if err != nil {
+			return nil, fmt.Errorf("configure overrides: %w", err)
+		}
+
+		c.overrides = o
+	}
+
+	if c.telemetryConfig != nil {
+		t, err := newTelemetry(c.telemetryConfig)
+		if err != nil {
+			return nil, fmt.Errorf("configure telemetry: %w", err)
+		}
+
+		c.hooks = append(c.hooks, t)
+	}
+
 	// Use test mode if LAUNCHDARKLY_CONFIGURATION isn't set OR if the user
 	// explicitly configured the client for test mode.
 	if _, ok := os.LookupEnv(configurationEnvVar); !ok || c.mode == modeTest {
@@ -63,7 +91,7 @@ func NewClient(opts ...ConfigOption) (*Client, error) {
 		return c, nil
 	}
 
-	parsedConfig, err := configFromEnvironment()
+	parsedConfig, err := configFromEnvironment(c.name)
 	if err != nil {
 		return nil, fmt.Errorf("configure from environment variable: %w", err)
 	}
@@ -103,57 +131,71 @@ func (c *Client) Connect() error {
 // extracted from the context. The supplied fallback value is always reflected in
 // the returned value regardless of whether an error occurs.
 func (c *Client) QueryBool(ctx context.Context, key FlagName, fallbackValue bool) (bool, error) {
-	user, err := evaluationcontext.UserFromContext(ctx)
-	if err != nil {
-		return fallbackValue, fmt.Errorf("get user from context: %w", err)
-	}
-
-	return c.wrappedClient.BoolVariation(string(key), user.ToLDUser(), fallbackValue)
+	return Query(ctx, key, fallbackValue, &QueryOptions{Client: c})
 }
 
 // QueryBoolWithEvaluationContext retrieves the value of a boolean flag. An evaluation context
 // must be supplied manually. The supplied fallback value is always reflected in the
 // returned value regardless of whether an error occurs.
 func (c *Client) QueryBoolWithEvaluationContext(key FlagName, evalContext evaluationcontext.Context, fallbackValue bool) (bool, error) {
-	return c.wrappedClient.BoolVariation(string(key), evalContext.ToLDUser(), fallbackValue)
+	return Query(context.Background(), key, fallbackValue, &QueryOptions{Client: c, EvaluationContext: evalContext})
 }
 
 // QueryString retrieves the value of a string flag. User attributes are
 // extracted from the context. The supplied fallback value is always reflected in
 // the returned value regardless of whether an error occurs.
 func (c *Client) QueryString(ctx context.Context, key FlagName, fallbackValue string) (string, error) {
-	user, err := evaluationcontext.UserFromContext(ctx)
-	if err != nil {
-		return fallbackValue, fmt.Errorf("get user from context: %w", err)
-	}
-
-	return c.wrappedClient.StringVariation(string(key), user.ToLDUser(), fallbackValue)
+	return Query(ctx, key, fallbackValue, &QueryOptions{Client: c})
 }
 
 // QueryStringWithEvaluationContext retrieves the value of a string flag. An evaluation context
 // must be supplied manually. The supplied fallback value is always reflected in the
 // returned value regardless of whether an error occurs.
 func (c *Client) QueryStringWithEvaluationContext(key FlagName, evalContext evaluationcontext.Context, fallbackValue string) (string, error) {
-	return c.wrappedClient.StringVariation(string(key), evalContext.ToLDUser(), fallbackValue)
+	return Query(context.Background(), key, fallbackValue, &QueryOptions{Client: c, EvaluationContext: evalContext})
 }
 
 // QueryInt retrieves the value of an integer flag. User attributes are
 // extracted from the context. The supplied fallback value is always reflected in
 // the returned value regardless of whether an error occurs.
 func (c *Client) QueryInt(ctx context.Context, key FlagName, fallbackValue int) (int, error) {
-	user, err := evaluationcontext.UserFromContext(ctx)
-	if err != nil {
-		return fallbackValue, fmt.Errorf("get user from context: %w", err)
-	}
-
-	return c.wrappedClient.IntVariation(string(key), user.ToLDUser(), fallbackValue)
+	return Query(ctx, key, fallbackValue, &QueryOptions{Client: c})
 }
 
 // QueryIntWithEvaluationContext retrieves the value of an integer flag. An evaluation context
 // must be supplied manually. The supplied fallback value is always reflected in the
 // returned value regardless of whether an error occurs.
 func (c *Client) QueryIntWithEvaluationContext(key FlagName, evalContext evaluationcontext.Context, fallbackValue int) (int, error) {
-	return c.wrappedClient.IntVariation(string(key), evalContext.ToLDUser(), fallbackValue)
+	return Query(context.Background(), key, fallbackValue, &QueryOptions{Client: c, EvaluationContext: evalContext})
+}
+
+// variationFunc is the shape of the typed *VariationDetail methods on the
+// wrapped LaunchDarkly client.
+type variationFunc[T any] func(key string, user lduser.User, fallbackValue T) (T, ldreason.EvaluationDetail, error)
+
+// queryFromContext evaluates a flag for the user extracted from the request
+// context.
+func queryFromContext[T any](ctx context.Context, c *Client, key FlagName, fallbackValue T, variation variationFunc[T]) (T, EvaluationDetail, error) {
+	user, err := evaluationcontext.UserFromContext(ctx)
+	if err != nil {
+		evaluation := Evaluation{Flag: key, FallbackValue: fallbackValue}
+
+		return evaluate(ctx, c, evaluation, fallbackValue, func() (T, ldreason.EvaluationDetail, error) {
+			detail := ldreason.NewEvaluationDetailForError(ldreason.EvalErrorUserNotSpecified, ldvalue.Null())
+			return fallbackValue, detail, fmt.Errorf("get user from context: %w", err)
+		})
+	}
+
+	return query[T](ctx, c, key, user, fallbackValue, variation)
+}
+
+// query evaluates a flag for the given evaluation context.
+func query[T any](ctx context.Context, c *Client, key FlagName, evalContext evaluationcontext.Context, fallbackValue T, variation variationFunc[T]) (T, EvaluationDetail, error) {
+	evaluation := Evaluation{Flag: key, EvaluationContext: evalContext, FallbackValue: fallbackValue}
+
+	return evaluate(ctx, c, evaluation, fallbackValue, func() (T, ldreason.EvaluationDetail, error) {
+		return variation(string(key), evalContext.ToLDUser(), fallbackValue)
+	})
 }
 
 // RawClient returns the wrapped LaunchDarkly client. The return value should be
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+var generatedTemplate = template.Must(template.New("generated").Funcs(template.FuncMap{
+	"goType":  func(t string) string { return flagTypes[t].goType },
+	"method":  func(t string) string { return flagTypes[t].method },
+	"literal": func(v interface{}) string { return fmt.Sprintf("%#v", v) },
+	"comment": comment,
+}).Parse(`// Code generated by flaggen from {{ .Source }}. DO NOT EDIT.
+
+package {{ .Package }}
+{{ if .Flags }}
+import (
+	"context"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+)
+
+// Flag names declared in {{ .Source }}.
+const (
+{{- range .Flags }}
+	{{ .Identifier }}Flag flags.FlagName = {{ literal .Name }}
+{{- end }}
+)
+
+func init() {
+	flags.Register(
+{{- range .Flags }}
+		flags.Definition{Name: {{ .Identifier }}Flag, Default: {{ literal .Default }}, Description: {{ literal .Description }}},
+{{- end }}
+	)
+}
+{{ range .Flags }}
+// {{ .Identifier }} returns the value of the {{ literal .Name }} flag. User
+// attributes are extracted from the context. The default value,
+// {{ literal .Default }}, is returned if an error occurs.
+{{- if .Description }}
+//
+{{ comment .Description }}
+{{- end }}
+{{- if or .Owner .Expires }}
+//
+{{- if .Owner }}
+{{ comment (printf "Owner: %s." .Owner) }}
+{{- end }}
+{{- if .Expires }}
+// Expires: {{ .Expires }}.
+{{- end }}
+{{- end }}
+func {{ .Identifier }}(ctx context.Context, client *flags.Client) ({{ goType .Type }}, error) {
+	return client.{{ method .Type }}(ctx, {{ .Identifier }}Flag, {{ literal .Default }})
+}
+
+// {{ .Identifier }}WithEvaluationContext returns the value of the {{ literal .Name }}
+// flag for the supplied evaluation context. The default value, {{ literal .Default }},
+// is returned if an error occurs.
+func {{ .Identifier }}WithEvaluationContext(client *flags.Client, evalContext evaluationcontext.Context) ({{ goType .Type }}, error) {
+	return client.{{ method .Type }}WithEvaluationContext({{ .Identifier }}Flag, evalContext, {{ literal .Default }})
+}
+{{ end }}
+{{- end }}`))
+
+// comment returns the text as a comment, with each line prefixed by "//" so
+// that text spanning several lines stays within the comment.
+func comment(text string) string {
+	lines := strings.Split(strings.TrimSpace(text), "\n")
+	for i, line := range lines {
+		line = strings.TrimRightFunc(line, unicode.IsSpace)
+		if line == "" {
+			lines[i] = "//"
+		} else {
+			lines[i] = "// " + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// generate renders the typed accessors for the flags in the manifest as
+// formatted Go source. For a manifest without flags, only the package clause
+// is generated, so that the package still compiles.
+func generate(m manifest, source, pkg string) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := generatedTemplate.Execute(&buf, struct {
+		Source  string
+		Package string
+		Flags   []manifestFlag
+	}{
+		Source:  source,
+		Package: pkg,
+		Flags:   m.Flags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+
+	return src, nil
+}
@@ -0,0 +1,142 @@
+// Command flaggen generates typed accessors for the feature flags declared in
+// a manifest, and reports flags that are past their expiry date.
+//
+// A manifest is a YAML (or JSON) file listing the flags used by a service:
+//   flags:
+//     - name: enable-new-dashboard
+//       type: bool
+//       default: false
+//       description: Shows the redesigned dashboard.
+//       owner: team-insights
+//       expires: 2024-06-30
+//     - name: export-batch-size
+//       type: int
+//       default: 100
+//
+// Supported types are bool, string and int. The default is returned when a
+// flag can't be evaluated, and defaults to the zero value of the type.
+//
+// Generate accessors with go:generate:
+//   //go:generate go run github.com/cultureamp/ca-go/x/launchdarkly/flags/cmd/flaggen generate -manifest flags.yaml -package myflags -out flags_gen.go
+//
+// For each flag, the generated file declares a FlagName constant (for example
+// EnableNewDashboardFlag) and two accessors against flags.Client:
+//   enabled, err := myflags.EnableNewDashboard(ctx, client)
+//   enabled, err := myflags.EnableNewDashboardWithEvaluationContext(client, evalContext)
+//
+// The generated file also registers each flag with flags.Register, so its
+// default can be written to a .ld-flags.json file with the flagfile package.
+//
+// Report flags that are past their expiry date, exiting with a non-zero status
+// if there are any. This is intended to be run in CI:
+//   flaggen lint -manifest flags.yaml
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+var errExpiredFlags = errors.New("manifest contains expired flags")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, time.Now()); err != nil {
+		fmt.Fprintf(os.Stderr, "flaggen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer, now time.Time) error {
+	if len(args) == 0 {
+		return errors.New("expected a command: generate or lint")
+	}
+
+	switch args[0] {
+	case "generate":
+		return generateCommand(args[1:], stdout)
+	case "lint":
+		return lintCommand(args[1:], stdout, now)
+	default:
+		return fmt.Errorf("unknown command %q: expected generate or lint", args[0])
+	}
+}
+
+func generateCommand(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	manifestFilename := fs.String("manifest", "", "path of the flag manifest")
+	pkg := fs.String("package", os.Getenv("GOPACKAGE"), "package name of the generated file (defaults to $GOPACKAGE)")
+	out := fs.String("out", "", `path to write the generated code to, or "-" for stdout`)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *manifestFilename == "" || *pkg == "" || *out == "" {
+		return errors.New("generate: -manifest, -package and -out are required")
+	}
+
+	m, err := readManifest(*manifestFilename)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	src, err := generate(m, filepath.Base(*manifestFilename), *pkg)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	if *out == "-" {
+		_, err = stdout.Write(src)
+		return err
+	}
+
+	if err := os.WriteFile(*out, src, 0o644); err != nil {
+		return fmt.Errorf("generate: write %s: %w", *out, err)
+	}
+
+	return nil
+}
+
+func lintCommand(args []string, stdout io.Writer, now time.Time) error {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	manifestFilename := fs.String("manifest", "", "path of the flag manifest")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *manifestFilename == "" {
+		return errors.New("lint: -manifest is required")
+	}
+
+	m, err := readManifest(*manifestFilename)
+	if err != nil {
+		return fmt.Errorf("lint: %w", err)
+	}
+
+	var expired int
+	for _, f := range m.Flags {
+		if !f.expired(now) {
+			continue
+		}
+
+		expired++
+
+		owner := f.Owner
+		if owner == "" {
+			owner = "no owner"
+		}
+		fmt.Fprintf(stdout, "%s: flag %q expired on %s (%s)\n", *manifestFilename, f.Name, f.Expires, owner)
+	}
+
+	if expired > 0 {
+		return errExpiredFlags
+	}
+
+	return nil
+}
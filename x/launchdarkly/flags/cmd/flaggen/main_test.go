@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validManifestYAML = `
+flags:
+  - name: enable-new-dashboard
+    type: bool
+    default: true
+    description: Shows the redesigned dashboard.
+    owner: team-insights
+    expires: 2024-06-30
+  - name: dashboard.theme
+    type: string
+  - name: export-batch-size
+    type: int
+    default: 100
+    expires: 2025-01-01
+`
+
+func TestGenerate(t *testing.T) {
+	manifest := writeManifest(t, "flags.yaml", validManifestYAML)
+
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"generate", "-manifest", manifest, "-package", "myflags", "-out", "-"}, &stdout, time.Now()))
+
+	src := stdout.String()
+	typeCheck(t, src)
+
+	assert.Contains(t, src, "// Code generated by flaggen from flags.yaml. DO NOT EDIT.")
+	assert.Contains(t, src, "package myflags")
+	assert.Contains(t, src, `EnableNewDashboardFlag flags.FlagName = "enable-new-dashboard"`)
+	assert.Contains(t, src, `flags.Definition{Name: DashboardThemeFlag, Default: "", Description: ""}`)
+	assert.Contains(t, src, "// Owner: team-insights.\n// Expires: 2024-06-30.\n")
+	assert.Contains(t, src, "func EnableNewDashboard(ctx context.Context, client *flags.Client) (bool, error) {\n\treturn client.QueryBool(ctx, EnableNewDashboardFlag, true)\n}")
+	assert.Contains(t, src, "func DashboardThemeWithEvaluationContext(client *flags.Client, evalContext evaluationcontext.Context) (string, error) {\n\treturn client.QueryStringWithEvaluationContext(DashboardThemeFlag, evalContext, \"\")\n}")
+	assert.Contains(t, src, "return client.QueryInt(ctx, ExportBatchSizeFlag, 100)")
+
+	t.Run("keeps multi-line text within comments", func(t *testing.T) {
+		manifest := writeManifest(t, "flags.yaml", `
+flags:
+  - name: multi-line
+    type: bool
+    description: |
+      Shows the redesigned dashboard.
+
+      Remove once the rollout is complete.
+    owner: "team-insights\nfunc Broken() {"
+`)
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"generate", "-manifest", manifest, "-package", "myflags", "-out", "-"}, &stdout, time.Now()))
+
+		src := stdout.String()
+		typeCheck(t, src)
+		assert.Contains(t, src, "// Shows the redesigned dashboard.\n//\n// Remove once the rollout is complete.\n")
+		assert.Contains(t, src, "// Owner: team-insights\n// func Broken() {.\n")
+	})
+
+	t.Run("compiles without flags", func(t *testing.T) {
+		manifest := writeManifest(t, "flags.yaml", "flags: []\n")
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"generate", "-manifest", manifest, "-package", "myflags", "-out", "-"}, &stdout, time.Now()))
+
+		src := stdout.String()
+		typeCheck(t, src)
+		assert.Equal(t, "// Code generated by flaggen from flags.yaml. DO NOT EDIT.\n\npackage myflags\n", src)
+	})
+
+	t.Run("writes to a file", func(t *testing.T) {
+		out := filepath.Join(t.TempDir(), "flags_gen.go")
+		require.NoError(t, run([]string{"generate", "-manifest", manifest, "-package", "myflags", "-out", out}, &bytes.Buffer{}, time.Now()))
+
+		contents, err := os.ReadFile(out)
+		require.NoError(t, err)
+		assert.Equal(t, src, string(contents))
+	})
+
+	t.Run("defaults the package to $GOPACKAGE", func(t *testing.T) {
+		t.Setenv("GOPACKAGE", "fromenv")
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"generate", "-manifest", manifest, "-out", "-"}, &stdout, time.Now()))
+		assert.Contains(t, stdout.String(), "package fromenv")
+	})
+
+	t.Run("requires a manifest", func(t *testing.T) {
+		require.Error(t, run([]string{"generate", "-package", "myflags", "-out", "-"}, &bytes.Buffer{}, time.Now()))
+	})
+}
+
+// typeCheck parses and type-checks the generated source, checking it against
+// the flags package of this module. Imports are read from the export data
+// built by the go command.
+func typeCheck(t *testing.T, src string) {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "flags_gen.go", src, 0)
+	require.NoError(t, err)
+
+	out, err := exec.Command("go", "list", "-export", "-deps", "-f", "{{.ImportPath}} {{.Export}}", "github.com/cultureamp/ca-go/x/launchdarkly/flags").Output()
+	require.NoError(t, err)
+
+	exports := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		path, export, _ := strings.Cut(line, " ")
+		exports[path] = export
+	}
+
+	lookup := func(path string) (io.ReadCloser, error) {
+		export, ok := exports[path]
+		if !ok || export == "" {
+			return nil, fmt.Errorf("no export data for %s", path)
+		}
+
+		return os.Open(export)
+	}
+
+	conf := types.Config{Importer: importer.ForCompiler(fset, "gc", lookup)}
+	_, err = conf.Check(file.Name.Name, fset, []*ast.File{file}, nil)
+	require.NoError(t, err, "type-check generated code")
+}
+
+func TestLint(t *testing.T) {
+	manifest := writeManifest(t, "flags.yaml", validManifestYAML)
+
+	t.Run("reports expired flags", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := run([]string{"lint", "-manifest", manifest}, &stdout, time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC))
+		require.ErrorIs(t, err, errExpiredFlags)
+		assert.Equal(t, manifest+": flag \"enable-new-dashboard\" expired on 2024-06-30 (team-insights)\n", stdout.String())
+	})
+
+	t.Run("succeeds when no flags have expired", func(t *testing.T) {
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"lint", "-manifest", manifest}, &stdout, time.Date(2024, 6, 29, 0, 0, 0, 0, time.UTC)))
+		assert.Empty(t, stdout.String())
+	})
+}
+
+func TestUnknownCommand(t *testing.T) {
+	require.Error(t, run(nil, &bytes.Buffer{}, time.Now()))
+	require.Error(t, run([]string{"bogus"}, &bytes.Buffer{}, time.Now()))
+}
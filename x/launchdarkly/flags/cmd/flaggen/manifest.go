@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+)
+
+const expiresLayout = "2006-01-02"
+
+// flagTypes maps the types supported in a manifest to the Go type of the
+// generated accessor and the flags.Client method it calls.
+var flagTypes = map[string]struct {
+	goType string
+	method string
+}{
+	"bool":   {goType: "bool", method: "QueryBool"},
+	"string": {goType: "string", method: "QueryString"},
+	"int":    {goType: "int", method: "QueryInt"},
+}
+
+// manifest is a list of the flags used by a service. Manifests are written in
+// YAML; because YAML is a superset of JSON, JSON manifests are also accepted.
+type manifest struct {
+	Flags []manifestFlag `yaml:"flags"`
+}
+
+type manifestFlag struct {
+	Name        string      `yaml:"name"`
+	Type        string      `yaml:"type"`
+	Default     interface{} `yaml:"default"`
+	Description string      `yaml:"description"`
+	Owner       string      `yaml:"owner"`
+	Expires     string      `yaml:"expires"`
+
+	// identifier is the exported Go identifier derived from Name.
+	identifier string
+
+	// expires is the parsed value of Expires, or the zero time if the flag
+	// does not expire.
+	expires time.Time
+}
+
+func readManifest(filename string) (manifest, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return manifest{}, fmt.Errorf("read manifest: %w", err)
+	}
+
+	var m manifest
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return manifest{}, fmt.Errorf("parse manifest %s: %w", filename, err)
+	}
+
+	if err := m.validate(); err != nil {
+		return manifest{}, fmt.Errorf("invalid manifest %s: %w", filename, err)
+	}
+
+	return m, nil
+}
+
+// validate checks every flag in the manifest, filling in the zero value for
+// missing defaults and deriving Go identifiers.
+func (m *manifest) validate() error {
+	names := map[string]bool{}
+	identifiers := map[string]string{}
+
+	for i := range m.Flags {
+		f := &m.Flags[i]
+
+		if f.Name == "" {
+			return fmt.Errorf("flag %d has no name", i+1)
+		}
+
+		if names[f.Name] {
+			return fmt.Errorf("flag %q is declared more than once", f.Name)
+		}
+		names[f.Name] = true
+
+		if err := f.validateDefault(); err != nil {
+			return fmt.Errorf("flag %q: %w", f.Name, err)
+		}
+
+		if f.Expires != "" {
+			expires, err := time.Parse(expiresLayout, f.Expires)
+			if err != nil {
+				return fmt.Errorf("flag %q: expires must be a date in the form YYYY-MM-DD: %w", f.Name, err)
+			}
+			f.expires = expires
+		}
+
+		f.identifier = identifier(f.Name)
+		for _, generated := range f.generatedIdentifiers() {
+			if other, ok := identifiers[generated]; ok {
+				return fmt.Errorf("flags %q and %q both generate the identifier %s", other, f.Name, generated)
+			}
+			identifiers[generated] = f.Name
+		}
+	}
+
+	return nil
+}
+
+func (f *manifestFlag) validateDefault() error {
+	var ok bool
+
+	switch f.Type {
+	case "bool":
+		if f.Default == nil {
+			f.Default = false
+		}
+		_, ok = f.Default.(bool)
+	case "string":
+		if f.Default == nil {
+			f.Default = ""
+		}
+		_, ok = f.Default.(string)
+	case "int":
+		if f.Default == nil {
+			f.Default = 0
+		}
+		_, ok = f.Default.(int)
+	case "":
+		return errors.New("type is required")
+	default:
+		return fmt.Errorf("unsupported type %q: expected bool, string or int", f.Type)
+	}
+
+	if !ok {
+		return fmt.Errorf("default %v is not a valid %s", f.Default, f.Type)
+	}
+
+	return nil
+}
+
+// Identifier returns the exported Go identifier derived from the flag name.
+func (f manifestFlag) Identifier() string {
+	return f.identifier
+}
+
+// generatedIdentifiers returns the identifiers declared by the generated code
+// for the flag: the FlagName constant and the two accessor functions.
+func (f manifestFlag) generatedIdentifiers() []string {
+	return []string{f.identifier, f.identifier + "Flag", f.identifier + "WithEvaluationContext"}
+}
+
+// expired reports whether the flag's expiry date is on or before the given
+// time.
+func (f manifestFlag) expired(now time.Time) bool {
+	return !f.expires.IsZero() && !now.Before(f.expires)
+}
+
+// identifier converts a flag name such as "my-flag.v2" into an exported Go
+// identifier such as "MyFlagV2".
+func identifier(name string) string {
+	var b strings.Builder
+
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	id := b.String()
+	if id == "" || !unicode.IsLetter([]rune(id)[0]) {
+		id = "Flag" + id
+	}
+
+	return id
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadManifest(t *testing.T) {
+	t.Run("reads YAML manifests", func(t *testing.T) {
+		m, err := readManifest(writeManifest(t, "flags.yaml", validManifestYAML))
+		require.NoError(t, err)
+		require.Len(t, m.Flags, 3)
+
+		assert.Equal(t, "EnableNewDashboard", m.Flags[0].Identifier())
+		assert.Equal(t, true, m.Flags[0].Default)
+		assert.Equal(t, time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC), m.Flags[0].expires)
+
+		assert.Equal(t, "", m.Flags[1].Default)
+		assert.Equal(t, 100, m.Flags[2].Default)
+	})
+
+	t.Run("reads JSON manifests", func(t *testing.T) {
+		m, err := readManifest(writeManifest(t, "flags.json", `{"flags": [{"name": "my-flag", "type": "int", "default": 3}]}`))
+		require.NoError(t, err)
+		require.Len(t, m.Flags, 1)
+		assert.Equal(t, 3, m.Flags[0].Default)
+	})
+
+	invalid := map[string]string{
+		"missing name":         `flags: [{type: bool}]`,
+		"missing type":         `flags: [{name: a}]`,
+		"unsupported type":     `flags: [{name: a, type: float}]`,
+		"mismatched default":   `flags: [{name: a, type: int, default: "x"}]`,
+		"duplicate name":       `flags: [{name: a, type: bool}, {name: a, type: bool}]`,
+		"duplicate identifier": `flags: [{name: a-b, type: bool}, {name: a.b, type: bool}]`,
+		"generated collision":  `flags: [{name: foo, type: bool}, {name: foo-flag, type: bool}]`,
+		"invalid expiry":       `flags: [{name: a, type: bool, expires: next week}]`,
+	}
+	for name, contents := range invalid {
+		contents := contents
+		t.Run("rejects "+name, func(t *testing.T) {
+			_, err := readManifest(writeManifest(t, "flags.yaml", contents))
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestIdentifier(t *testing.T) {
+	assert.Equal(t, "MyFlag", identifier("my-flag"))
+	assert.Equal(t, "MyFlagV2", identifier("my.flag.v2"))
+	assert.Equal(t, "MyFlag", identifier("my_flag"))
+	assert.Equal(t, "Flag2fa", identifier("2fa"))
+}
+
+func writeManifest(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filename, []byte(contents), 0o600))
+
+	return filename
+}
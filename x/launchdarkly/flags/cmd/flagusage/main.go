@@ -0,0 +1,75 @@
+// Command flagusage reports stale feature flags: flags that are referenced in
+// code but not defined in LaunchDarkly, and flags that are defined in
+// LaunchDarkly but never referenced in code.
+//
+// Compare the packages of the current module with a .ld-flags.json file or
+// flag data exported from LaunchDarkly (see the ldflags command). The command
+// exits with a non-zero status if there are any findings:
+//   flagusage -flags export.json ./...
+//
+// If no packages are given, ./... is checked. See the flagusage package for
+// the rules used to find flag references.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagfile"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagusage"
+)
+
+var errStaleFlags = errors.New("found stale flags")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "flagusage: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("flagusage", flag.ContinueOnError)
+	flagsFilename := fs.String("flags", "", "path of a .ld-flags.json file or flag data exported from LaunchDarkly")
+	dir := fs.String("dir", ".", "directory to resolve package patterns from")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *flagsFilename == "" {
+		return errors.New("-flags is required")
+	}
+
+	patterns := fs.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+
+	reference, err := flagfile.ReadFile(*flagsFilename)
+	if err != nil {
+		return err
+	}
+
+	report, err := flagusage.Check(*dir, patterns, reference)
+	if err != nil {
+		return err
+	}
+
+	for _, ref := range report.Undefined {
+		fmt.Fprintf(stdout, "%s: flag %q is referenced but not defined in LaunchDarkly\n", ref.Pos, ref.Flag)
+	}
+
+	for _, name := range report.Unreferenced {
+		fmt.Fprintf(stdout, "flag %q is defined in LaunchDarkly but never referenced\n", name)
+	}
+
+	if !report.Empty() {
+		return errStaleFlags
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const usageDir = "../../flagusage/testdata/usage"
+
+func TestRun(t *testing.T) {
+	t.Run("reports stale flags", func(t *testing.T) {
+		var stdout bytes.Buffer
+		err := run([]string{"-flags", "../../flagusage/testdata/flags.json", usageDir}, &stdout)
+		require.ErrorIs(t, err, errStaleFlags)
+
+		out := stdout.String()
+		assert.Contains(t, out, `usage.go:11:2: flag "undefined-flag" is referenced but not defined in LaunchDarkly`)
+		assert.Contains(t, out, `usage.go:20:25: flag "undefined-literal-flag" is referenced but not defined in LaunchDarkly`)
+		assert.Contains(t, out, `flag "unused-flag" is defined in LaunchDarkly but never referenced`)
+	})
+
+	t.Run("succeeds when there are no stale flags", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), ".ld-flags.json")
+		require.NoError(t, os.WriteFile(filename, []byte(`{"flagValues": {
+			"defined-flag": true,
+			"undefined-flag": true,
+			"literal-flag": "",
+			"undefined-literal-flag": 0,
+			"converted-flag": 0,
+			"test-only-flag": true,
+			"undefined-test-flag": true
+		}}`), 0o600))
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"-flags", filename, usageDir}, &stdout))
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("requires flag data", func(t *testing.T) {
+		require.Error(t, run([]string{usageDir}, &bytes.Buffer{}))
+		require.Error(t, run([]string{"-flags", "does-not-exist.json", usageDir}, &bytes.Buffer{}))
+	})
+}
@@ -0,0 +1,154 @@
+// Command ldflags produces and checks .ld-flags.json files, which the flags
+// package uses as the source of flag data in test mode.
+//
+// Generate a .ld-flags.json file from flag data exported from LaunchDarkly
+// (from the Relay Proxy, an SDK endpoint, or the REST API):
+//   ldflags generate -from export.json -out .ld-flags.json
+//
+// Generate a .ld-flags.json file from the default values of the flags declared
+// with flags.Register by packages of the module in the current directory:
+//   ldflags generate -registry ./internal/flags ./internal/billing
+//
+// The packages are imported by a temporary program built with the go command.
+// When both -from and -registry are supplied, values are taken from the
+// export, and the registered defaults are used for flags that are off and
+// have no off variation.
+//
+// Compare a local .ld-flags.json file against a reference export, listing
+// flags that are missing, extra or have different values. The command exits
+// with a non-zero status if any flags are missing:
+//   ldflags diff -local .ld-flags.json -reference export.json
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagfile"
+)
+
+const defaultFlagsFilename = ".ld-flags.json"
+
+var errMissingFlags = errors.New("local flag data is missing flags")
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "ldflags: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return errors.New("expected a command: generate or diff")
+	}
+
+	switch args[0] {
+	case "generate":
+		return generate(args[1:], stdout)
+	case "diff":
+		return diff(args[1:], stdout)
+	default:
+		return fmt.Errorf("unknown command %q: expected generate or diff", args[0])
+	}
+}
+
+func generate(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("generate", flag.ContinueOnError)
+	from := fs.String("from", "", "path of the flag data exported from LaunchDarkly")
+	registry := fs.Bool("registry", false, "read the flags registered by the packages named by the arguments")
+	out := fs.String("out", defaultFlagsFilename, `path to write the flag data to, or "-" for stdout`)
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *from == "" && !*registry {
+		return errors.New("generate: -from or -registry is required")
+	}
+
+	if *registry && fs.NArg() == 0 {
+		return errors.New("generate: -registry requires at least one package")
+	}
+
+	var defs []flags.Definition
+	if *registry {
+		var err error
+		if defs, err = registeredDefinitions(fs.Args()); err != nil {
+			return fmt.Errorf("generate: %w", err)
+		}
+	}
+
+	var (
+		f   flagfile.File
+		err error
+	)
+	if *from != "" {
+		f, err = flagfile.ReadFile(*from, flagfile.WithDefaults(defs))
+	} else {
+		f, err = flagfile.FromDefinitions(defs)
+	}
+
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	if *out == "-" {
+		return f.Write(stdout)
+	}
+
+	if err := f.WriteFile(*out); err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	fmt.Fprintf(stdout, "wrote %d flags to %s\n", len(f.FlagValues), *out)
+
+	return nil
+}
+
+func diff(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	localFilename := fs.String("local", defaultFlagsFilename, "path of the local flag data")
+	referenceFilename := fs.String("reference", "", "path of the flag data exported from LaunchDarkly")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *referenceFilename == "" {
+		return errors.New("diff: -reference is required")
+	}
+
+	local, err := flagfile.ReadFile(*localFilename)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	reference, err := flagfile.ReadFile(*referenceFilename)
+	if err != nil {
+		return fmt.Errorf("diff: %w", err)
+	}
+
+	d := flagfile.Compare(local, reference)
+	for _, name := range d.Missing {
+		fmt.Fprintf(stdout, "missing: %s\n", name)
+	}
+
+	for _, name := range d.Extra {
+		fmt.Fprintf(stdout, "extra: %s\n", name)
+	}
+
+	for _, name := range d.Changed {
+		fmt.Fprintf(stdout, "changed: %s\n", name)
+	}
+
+	if len(d.Missing) > 0 {
+		return errMissingFlags
+	}
+
+	return nil
+}
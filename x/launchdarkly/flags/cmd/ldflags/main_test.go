@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const exportJSON = `
+{
+	"flags": {
+		"bool-flag": {"on": true, "variations": [true, false], "fallthrough": {"variation": 0}},
+		"string-flag": {"on": false, "variations": ["a", "b"], "offVariation": 1}
+	}
+}
+`
+
+func writeFile(t *testing.T, name, contents string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filename, []byte(contents), 0o600))
+
+	return filename
+}
+
+func TestGenerate(t *testing.T) {
+	export := writeFile(t, "export.json", exportJSON)
+	out := filepath.Join(t.TempDir(), ".ld-flags.json")
+
+	var stdout bytes.Buffer
+	require.NoError(t, run([]string{"generate", "-from", export, "-out", out}, &stdout))
+	assert.Equal(t, "wrote 2 flags to "+out+"\n", stdout.String())
+
+	contents, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"flagValues": {"bool-flag": true, "string-flag": "b"}}`, string(contents))
+
+	stdout.Reset()
+	require.NoError(t, run([]string{"generate", "-from", export, "-out", "-"}, &stdout))
+	assert.JSONEq(t, `{"flagValues": {"bool-flag": true, "string-flag": "b"}}`, stdout.String())
+
+	require.Error(t, run([]string{"generate"}, &stdout))
+}
+
+func TestDiff(t *testing.T) {
+	export := writeFile(t, "export.json", exportJSON)
+
+	t.Run("reports missing, extra and changed flags", func(t *testing.T) {
+		local := writeFile(t, ".ld-flags.json", `{"flagValues": {"string-flag": "a", "other-flag": 1}}`)
+
+		var stdout bytes.Buffer
+		err := run([]string{"diff", "-local", local, "-reference", export}, &stdout)
+		require.ErrorIs(t, err, errMissingFlags)
+		assert.Equal(t, "missing: bool-flag\nextra: other-flag\nchanged: string-flag\n", stdout.String())
+	})
+
+	t.Run("succeeds when no flags are missing", func(t *testing.T) {
+		local := writeFile(t, ".ld-flags.json", `{"flagValues": {"bool-flag": true, "string-flag": "b"}}`)
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"diff", "-local", local, "-reference", export}, &stdout))
+		assert.Empty(t, stdout.String())
+	})
+
+	t.Run("requires a reference", func(t *testing.T) {
+		require.Error(t, run([]string{"diff"}, &bytes.Buffer{}))
+	})
+}
+
+func TestUnknownCommand(t *testing.T) {
+	require.Error(t, run(nil, &bytes.Buffer{}))
+	require.Error(t, run([]string{"bogus"}, &bytes.Buffer{}))
+}
+
+func TestGenerateFromRegistry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds a program with the go command")
+	}
+
+	t.Run("writes the registered defaults", func(t *testing.T) {
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"generate", "-registry", "-out", "-", "./testdata/registry"}, &stdout))
+		assert.JSONEq(t, `{"flagValues": {
+			"bool-flag": true,
+			"string-flag": "registered",
+			"limits-flag": {"maxUsers": 10}
+		}}`, stdout.String())
+	})
+
+	t.Run("fills off flags with no off variation from the registered defaults", func(t *testing.T) {
+		export := writeFile(t, "export.json", `{"flags": {
+			"bool-flag": {"on": true, "variations": [true, false], "fallthrough": {"variation": 1}},
+			"string-flag": {"on": false, "variations": ["a", "b"]},
+			"other-flag": {"on": false, "variations": [1, 2]}
+		}}`)
+
+		var stdout bytes.Buffer
+		require.NoError(t, run([]string{"generate", "-from", export, "-registry", "-out", "-", "./testdata/registry"}, &stdout))
+		assert.JSONEq(t, `{"flagValues": {"bool-flag": false, "string-flag": "registered"}}`, stdout.String())
+	})
+
+	t.Run("requires packages", func(t *testing.T) {
+		require.Error(t, run([]string{"generate", "-registry"}, &bytes.Buffer{}))
+	})
+
+	t.Run("rejects main packages", func(t *testing.T) {
+		err := run([]string{"generate", "-registry", "-out", "-", "."}, &bytes.Buffer{})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is a main package")
+	})
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+)
+
+// registryProgram prints the flags registered by the imported packages as
+// JSON. It is run with `go run` from the current directory, so the packages
+// are resolved by the module being worked on.
+var registryProgram = template.Must(template.New("registry").Parse(`package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+{{ range . }}
+	_ "{{ . }}"
+{{- end }}
+)
+
+func main() {
+	if err := json.NewEncoder(os.Stdout).Encode(flags.Definitions()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+`))
+
+// registeredDefinitions returns the flag definitions registered with
+// flags.Register by the given packages, which are named as for `go build`.
+// The packages are imported by a temporary program built by the go command.
+func registeredDefinitions(patterns []string) ([]flags.Definition, error) {
+	importPaths, err := resolvePackages(patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "ldflags-")
+	if err != nil {
+		return nil, fmt.Errorf("create registry program: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var src bytes.Buffer
+	if err := registryProgram.Execute(&src, importPaths); err != nil {
+		return nil, fmt.Errorf("create registry program: %w", err)
+	}
+
+	filename := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(filename, src.Bytes(), 0o600); err != nil {
+		return nil, fmt.Errorf("create registry program: %w", err)
+	}
+
+	// #nosec G204 -- the program is generated above from resolved import paths
+	out, err := goCommand("run", filename)
+	if err != nil {
+		return nil, fmt.Errorf("run registry program: %w", err)
+	}
+
+	var defs []flags.Definition
+	if err := json.Unmarshal(out, &defs); err != nil {
+		return nil, fmt.Errorf("read registered flags: %w", err)
+	}
+
+	return defs, nil
+}
+
+// resolvePackages returns the import paths of the packages named by the
+// patterns, which must not be main packages.
+func resolvePackages(patterns []string) ([]string, error) {
+	args := append([]string{"list", "-find", "-f", "{{.ImportPath}} {{.Name}}"}, patterns...)
+
+	out, err := goCommand(args...)
+	if err != nil {
+		return nil, fmt.Errorf("list packages: %w", err)
+	}
+
+	var importPaths []string
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		importPath, name, _ := strings.Cut(line, " ")
+		if name == "main" {
+			return nil, fmt.Errorf("package %s is a main package and can't be imported", importPath)
+		}
+
+		importPaths = append(importPaths, importPath)
+	}
+
+	return importPaths, nil
+}
+
+// goCommand runs the go command, returning its standard output. Its standard
+// error is included in any error returned.
+func goCommand(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.Command("go", args...)
+	cmd.Stderr = &stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return out, nil
+}
@@ -0,0 +1,12 @@
+// Package registry registers flags for the tests of the ldflags command.
+package registry
+
+import "github.com/cultureamp/ca-go/x/launchdarkly/flags"
+
+func init() {
+	flags.Register(
+		flags.Definition{Name: "bool-flag", Default: true},
+		flags.Definition{Name: "string-flag", Default: "registered"},
+		flags.Definition{Name: "limits-flag", Default: map[string]int{"maxUsers": 10}},
+	)
+}
@@ -24,7 +24,9 @@ const (
 )
 
 // configurationJSON declares the structure of the LAUNCHDARKLY_CONFIGURATION
-// environment variable.
+// environment variable. Additional SDK keys (for example, for other
+// LaunchDarkly projects) can be declared as named entries under "clients",
+// each with the same structure as the top-level configuration.
 type configurationJSON struct {
 	SDKKey  string `json:"sdkKey"`
 	Options struct {
@@ -35,6 +37,7 @@ type configurationJSON struct {
 			RelayProxyURL string `json:"url"`
 		} `json:"proxyMode"`
 	} `json:"options"`
+	Clients map[string]configurationJSON `json:"clients"`
 }
 
 // ProxyModeConfig declares optional overrides for configuring the client
@@ -55,7 +58,14 @@ type LambdaModeConfig struct {
 // JSON file as the source of flag data.
 type TestModeConfig struct {
 	FlagFilename string
-	datasource   *ldtestdata.TestDataSource
+
+	// IgnoreDefaultFlagsFile configures the client to ignore a .ld-flags.json
+	// file in the working directory, which otherwise takes precedence over
+	// both FlagFilename and the dynamic test data source. Set this to isolate
+	// tests from a developer's local flag data.
+	IgnoreDefaultFlagsFile bool
+
+	datasource *ldtestdata.TestDataSource
 }
 
 // ConfigOption are functions that can be supplied to Configure and NewClient to
@@ -72,6 +82,16 @@ func WithInitWait(t time.Duration) ConfigOption {
 	}
 }
 
+// WithClientName configures the client from the entry with the given name in
+// the "clients" section of the LAUNCHDARKLY_CONFIGURATION environment variable,
+// rather than from the top-level SDK key and options. This is set
+// automatically by ConfigureClient.
+func WithClientName(name string) ConfigOption {
+	return func(c *Client) {
+		c.name = name
+	}
+}
+
 // WithLambdaMode configures the client to connect to Dynamo for flags.
 func WithLambdaMode(cfg *LambdaModeConfig) ConfigOption {
 	return func(c *Client) {
@@ -104,7 +124,7 @@ func WithTestMode(cfg *TestModeConfig) ConfigOption {
 	}
 }
 
-func configFromEnvironment() (configurationJSON, error) {
+func configFromEnvironment(name string) (configurationJSON, error) {
 	parsedConfig := configurationJSON{}
 
 	configEnvVar, ok := os.LookupEnv(configurationEnvVar)
@@ -116,6 +136,17 @@ func configFromEnvironment() (configurationJSON, error) {
 		return parsedConfig, fmt.Errorf("parse %s: %w", configurationEnvVar, err)
 	}
 
+	// Use the named entry in place of the top-level configuration if the
+	// client was given a name.
+	if name != "" {
+		namedConfig, ok := parsedConfig.Clients[name]
+		if !ok {
+			return parsedConfig, fmt.Errorf("%s did not contain configuration for client %q", configurationEnvVar, name)
+		}
+
+		parsedConfig = namedConfig
+	}
+
 	// At a minimum the JSON should have an SDK key.
 	if parsedConfig.SDKKey == "" {
 		return parsedConfig, fmt.Errorf("%s did not contain an SDK key", configurationEnvVar)
@@ -163,7 +194,7 @@ func configForLambdaMode(env configurationJSON, cfg *LambdaModeConfig) ld.Config
 func configForTestMode(cfg *TestModeConfig) ld.Config {
 	// 1. If a .ld-flags.json file exists in the directory the binary was
 	// executed from, use that as the test data source.
-	if _, err := os.Stat(flagsJSONFilename); err == nil {
+	if _, err := os.Stat(flagsJSONFilename); err == nil && !cfg.IgnoreDefaultFlagsFile {
 		return ld.Config{
 			DataSource: ldfiledata.DataSource().
 				FilePaths(flagsJSONFilename).Reloader(ldfilewatch.WatchFiles),
@@ -43,6 +43,30 @@
 //     // handle invalid configuration
 //   }
 //
+// Services that query flags from more than one LaunchDarkly project can
+// register additional managed clients by name. Each name must have a matching
+// entry in the "clients" section of LAUNCHDARKLY_CONFIGURATION, which has the
+// same structure as the top-level configuration:
+//   {
+//     "sdkKey": "...",
+//     "options": { ... },
+//     "clients": {
+//       "other-project": { "sdkKey": "...", "options": { ... } }
+//     }
+//   }
+//
+// Named clients are configured, connected and retrieved independently of the
+// default client, and can be configured with different options:
+//   err := flags.ConfigureClient("other-project", flags.WithLambdaMode(nil))
+//   if err != nil {
+//     // handle invalid configuration
+//   }
+//
+//   err = flags.ConnectClient("other-project")
+//   ...
+//
+//   client, err := flags.GetClient("other-project")
+//
 // The client will attempt to proxy requests through the LD Relay by default. You
 // can optionally choose to connect directly to DynamoDB by specifying the
 // WithLambdaMode() option to the flags.NewClient() or flags.Configure() functions.
@@ -75,6 +99,66 @@
 //
 //   val, err := client.QueryBoolWithEvaluationContext("my-flag", user, false)
 //
+// The generic Query function supports flags of any primitive type, taking the
+// type from the fallback value, and QueryJSON unmarshals object and array
+// flags into a Go type. Both accept optional QueryOptions to supply an
+// evaluation context or a client (the managed singleton is used otherwise),
+// and to capture the evaluation reason:
+//   ratio, err := flags.Query(ctx, "sample-ratio", 0.1, nil)
+//
+//   var detail flags.EvaluationDetail
+//   limits, err := flags.QueryJSON(ctx, "account-limits", defaultLimits, &flags.QueryOptions{
+//     Client: client,
+//     Detail: &detail,
+//   })
+//
+// High-volume code paths that can't afford an SDK call per event can compute
+// percentage rollouts locally with Rollout, which uses the same bucketing
+// algorithm as LaunchDarkly. The flag key and salt must match the flag:
+//   rollout := flags.Rollout{Flag: "sample-events", Salt: "..."}
+//   if rollout.Includes(user, 10) {
+//     // the user is in the first 10% of the rollout
+//   }
+//
+// Flag evaluations can be instrumented with OpenTelemetry by supplying the
+// WithTelemetry option. Counters are recorded for evaluations, errors and
+// fallback values served, and span events can optionally be added to the span
+// in the request context:
+//   client, err := flags.NewClient(
+//     flags.WithTelemetry(&flags.TelemetryConfig{SpanEvents: true}),
+//   )
+//
+// Custom events, such as conversion events for experiments, are recorded with
+// the Track methods. Like queries, the user is extracted from the request
+// context:
+//   err := client.TrackMetric(ctx, "checkout-completed", orderTotal, nil)
+//
+// In test mode, tracked events are not sent to LaunchDarkly. They are recorded
+// instead, and can be inspected in tests using TrackedEvents().
+//
+// Cross-cutting behaviour such as audit logging can be added to every
+// evaluation by implementing the Hook interface and supplying it with the
+// WithHooks option. Hooks are run before and after each Query call, in the
+// order they were registered, and may alter the result:
+//   client, err := flags.NewClient(flags.WithHooks(auditHook, metricsHook))
+//
+// Flag values can be overridden locally, taking precedence over LaunchDarkly,
+// by supplying the WithOverrides option. Overrides are sourced from
+// environment variables (FLAG_OVERRIDE_<NAME>) and/or a JSON file, and
+// continue to work if LaunchDarkly is unavailable, so they can be used as an
+// emergency kill switch:
+//   client, err := flags.NewClient(
+//     flags.WithOverrides(&flags.OverrideConfig{FromEnvironment: true}),
+//   )
+//
+// Flags used by a service can be declared with Register, so that tooling can
+// discover them. The flaggen command (in cmd/flaggen) generates FlagName
+// constants, typed accessors and Register calls from a manifest of flags, and
+// reports flags that are past their expiry date. The ldflags command (in
+// cmd/ldflags) and the flagfile package produce and check .ld-flags.json files.
+// The flagusage command (in cmd/flagusage) finds flags that are referenced in
+// code but missing from LaunchDarkly, or defined but never referenced.
+//
 // When your application is shutting down, you should call Shutdown() to gracefully
 // close connections to LaunchDarkly:
 //   client.Shutdown()
@@ -22,4 +22,22 @@
 // you send in the query. You do not need to prefix the values provided to
 // the constructor functions with the entity type. For example, supply the user
 // ID as-is rather than prefixing as `user.<user-id>`.
+//
+// Evaluation contexts can be serialised with Marshal and restored with
+// Unmarshal, so that work handed to another process evaluates flags for the
+// same user as the process that produced it. Users also implement
+// json.Marshaler and json.Unmarshaler, so they can be embedded in message
+// bodies such as EventBridge event details. Helpers are provided to carry a
+// context in SQS and SNS message attributes:
+//   attrs := map[string]*sqs.MessageAttributeValue{}
+//   err := evaluationcontext.AddToSQSMessageAttributes(attrs, user)
+//   ...
+//
+//   // In the consuming Lambda function:
+//   evalContext, err := evaluationcontext.FromSQSEventMessage(msg)
+//   if err != nil {
+//     // handle messages without an evaluation context
+//   }
+//
+//   enabled, err := client.QueryBoolWithEvaluationContext("my-flag", evalContext, false)
 package evaluationcontext
@@ -0,0 +1,151 @@
+package evaluationcontext
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
+)
+
+const (
+	kindUser   = "user"
+	kindLDUser = "ldUser"
+)
+
+// userJSON is the serialised form of a User.
+type userJSON struct {
+	Kind       string `json:"kind"`
+	Key        string `json:"key"`
+	Anonymous  bool   `json:"anonymous,omitempty"`
+	AccountID  string `json:"accountID,omitempty"`
+	RealUserID string `json:"realUserID,omitempty"`
+
+	Locale            string `json:"locale,omitempty"`
+	Timezone          string `json:"timezone,omitempty"`
+	ClientApplication string `json:"clientApplication,omitempty"`
+	ClientVersion     string `json:"clientVersion,omitempty"`
+	SourceIP          string `json:"sourceIP,omitempty"`
+	UserAgent         string `json:"userAgent,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler, so that a User can be sent to
+// another process, for example in a queue message, and restored with
+// UnmarshalJSON.
+func (u User) MarshalJSON() ([]byte, error) {
+	return json.Marshal(userJSON{
+		Kind:       kindUser,
+		Key:        u.key,
+		Anonymous:  u.ldUser.GetAnonymous(),
+		AccountID:  u.accountID,
+		RealUserID: u.realUserID,
+
+		Locale:            u.metadata.Locale,
+		Timezone:          u.metadata.Timezone,
+		ClientApplication: u.metadata.ClientApplication,
+		ClientVersion:     u.metadata.ClientVersion,
+		SourceIP:          u.metadata.SourceIP,
+		UserAgent:         u.metadata.UserAgent,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler, restoring a User serialised with
+// MarshalJSON.
+func (u *User) UnmarshalJSON(data []byte) error {
+	var j userJSON
+	if err := json.Unmarshal(data, &j); err != nil {
+		return err
+	}
+
+	if j.Kind != kindUser {
+		return fmt.Errorf("unmarshal user: unexpected kind %q", j.Kind)
+	}
+
+	if j.Key == "" {
+		return fmt.Errorf("unmarshal user: missing key")
+	}
+
+	if j.Anonymous {
+		*u = NewAnonymousUser(j.Key)
+		return nil
+	}
+
+	*u = NewUser(
+		j.Key,
+		WithAccountID(j.AccountID),
+		WithRealUserID(j.RealUserID),
+		WithRequestMetadata(request.RequestMetadata{
+			Locale:            j.Locale,
+			Timezone:          j.Timezone,
+			ClientApplication: j.ClientApplication,
+			ClientVersion:     j.ClientVersion,
+			SourceIP:          j.SourceIP,
+			UserAgent:         j.UserAgent,
+		}))
+
+	return nil
+}
+
+// ldUserContext is a Context restored from the LaunchDarkly user of a Context
+// type this package doesn't define.
+type ldUserContext struct {
+	user lduser.User
+}
+
+func (c ldUserContext) ToLDUser() lduser.User {
+	return c.user
+}
+
+// ldUserJSON is the serialised form of a Context that isn't defined by this
+// package, which is serialised as its LaunchDarkly user.
+type ldUserJSON struct {
+	Kind string      `json:"kind"`
+	User lduser.User `json:"user"`
+}
+
+// Marshal serialises any evaluation context to JSON, to be restored by
+// Unmarshal. Contexts defined by this package are restored with their
+// original type; other implementations of Context are restored as a Context
+// with the same LaunchDarkly user.
+func Marshal(c Context) ([]byte, error) {
+	switch c := c.(type) {
+	case User:
+		return c.MarshalJSON()
+	case *User:
+		if c == nil {
+			return nil, fmt.Errorf("marshal evaluation context: user is nil")
+		}
+		return c.MarshalJSON()
+	case nil:
+		return nil, fmt.Errorf("marshal evaluation context: context is nil")
+	default:
+		return json.Marshal(ldUserJSON{Kind: kindLDUser, User: c.ToLDUser()})
+	}
+}
+
+// Unmarshal restores an evaluation context serialised by Marshal.
+func Unmarshal(data []byte) (Context, error) {
+	var kind struct {
+		Kind string `json:"kind"`
+	}
+	if err := json.Unmarshal(data, &kind); err != nil {
+		return nil, fmt.Errorf("unmarshal evaluation context: %w", err)
+	}
+
+	switch kind.Kind {
+	case kindUser:
+		var u User
+		if err := json.Unmarshal(data, &u); err != nil {
+			return nil, fmt.Errorf("unmarshal evaluation context: %w", err)
+		}
+		return u, nil
+	case kindLDUser:
+		var j ldUserJSON
+		if err := json.Unmarshal(data, &j); err != nil {
+			return nil, fmt.Errorf("unmarshal evaluation context: %w", err)
+		}
+		return ldUserContext{user: j.User}, nil
+	default:
+		return nil, fmt.Errorf("unmarshal evaluation context: unknown kind %q", kind.Kind)
+	}
+}
@@ -0,0 +1,136 @@
+package evaluationcontext_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+type customContext struct {
+	user lduser.User
+}
+
+func (c customContext) ToLDUser() lduser.User {
+	return c.user
+}
+
+func TestUserJSON(t *testing.T) {
+	t.Run("round-trips an identified user", func(t *testing.T) {
+		user := evaluationcontext.NewUser(
+			"user-id",
+			evaluationcontext.WithAccountID("account-id"),
+			evaluationcontext.WithRealUserID("real-user-id"))
+
+		data, err := json.Marshal(user)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"kind":"user","key":"user-id","accountID":"account-id","realUserID":"real-user-id"}`, string(data))
+
+		var restored evaluationcontext.User
+		require.NoError(t, json.Unmarshal(data, &restored))
+		assert.Equal(t, user, restored)
+		assertUserAttributes(t, restored, "user-id", "real-user-id", "account-id")
+	})
+
+	t.Run("round-trips request metadata", func(t *testing.T) {
+		user := evaluationcontext.NewUser(
+			"user-id",
+			evaluationcontext.WithRequestMetadata(request.RequestMetadata{
+				Locale:   "en-AU",
+				SourceIP: "203.0.113.7",
+			}))
+
+		data, err := json.Marshal(user)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"kind":"user","key":"user-id","locale":"en-AU","sourceIP":"203.0.113.7"}`, string(data))
+
+		var restored evaluationcontext.User
+		require.NoError(t, json.Unmarshal(data, &restored))
+		assert.Equal(t, user, restored)
+	})
+
+	t.Run("round-trips an anonymous user", func(t *testing.T) {
+		user := evaluationcontext.NewAnonymousUser("request-id")
+
+		data, err := json.Marshal(user)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"kind":"user","key":"request-id","anonymous":true}`, string(data))
+
+		var restored evaluationcontext.User
+		require.NoError(t, json.Unmarshal(data, &restored))
+		assert.Equal(t, user, restored)
+		assert.True(t, restored.ToLDUser().GetAnonymous())
+	})
+
+	t.Run("rejects invalid users", func(t *testing.T) {
+		var restored evaluationcontext.User
+		require.Error(t, json.Unmarshal([]byte(`{"kind":"user"}`), &restored))
+		require.Error(t, json.Unmarshal([]byte(`{"kind":"account","key":"id"}`), &restored))
+		require.Error(t, json.Unmarshal([]byte(`[]`), &restored))
+	})
+}
+
+func TestMarshal(t *testing.T) {
+	t.Run("restores users with their type", func(t *testing.T) {
+		user := evaluationcontext.NewUser("user-id", evaluationcontext.WithAccountID("account-id"))
+
+		data, err := evaluationcontext.Marshal(user)
+		require.NoError(t, err)
+
+		restored, err := evaluationcontext.Unmarshal(data)
+		require.NoError(t, err)
+		assert.Equal(t, user, restored)
+	})
+
+	t.Run("restores other contexts as their LaunchDarkly user", func(t *testing.T) {
+		c := customContext{lduser.NewUserBuilder("custom-key").Custom("team", ldvalue.String("platform")).Build()}
+
+		data, err := evaluationcontext.Marshal(c)
+		require.NoError(t, err)
+
+		restored, err := evaluationcontext.Unmarshal(data)
+		require.NoError(t, err)
+		assert.Equal(t, "custom-key", restored.ToLDUser().GetKey())
+		assert.Equal(t, "platform", restored.ToLDUser().GetAttribute("team").StringValue())
+	})
+
+	t.Run("returns errors for invalid input", func(t *testing.T) {
+		_, err := evaluationcontext.Marshal(nil)
+		require.Error(t, err)
+
+		_, err = evaluationcontext.Marshal((*evaluationcontext.User)(nil))
+		require.Error(t, err)
+
+		_, err = evaluationcontext.Unmarshal([]byte(`{"kind":"unknown"}`))
+		require.Error(t, err)
+
+		_, err = evaluationcontext.Unmarshal([]byte(`not json`))
+		require.Error(t, err)
+	})
+}
+
+func ExampleMarshal() {
+	user := evaluationcontext.NewUser("user-id", evaluationcontext.WithAccountID("account-id"))
+
+	data, err := evaluationcontext.Marshal(user)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(data))
+
+	restored, err := evaluationcontext.Unmarshal(data)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(restored.ToLDUser().GetKey())
+
+	// Output:
+	// {"kind":"user","key":"user-id","accountID":"account-id"}
+	// user-id
+}
@@ -0,0 +1,94 @@
+package evaluationcontext
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+// MessageAttributeName is the name of the message attribute that holds a
+// serialised evaluation context.
+const MessageAttributeName = "evaluation-context"
+
+const messageAttributeDataType = "String"
+
+// ErrNoEvaluationContext is returned when a message has no evaluation context
+// attribute.
+var ErrNoEvaluationContext = errors.New("message has no evaluation context attribute")
+
+// AddToSQSMessageAttributes serialises the evaluation context into the
+// MessageAttributeName attribute of an SQS message that is about to be sent.
+// The attributes map must not be nil.
+func AddToSQSMessageAttributes(attrs map[string]*sqs.MessageAttributeValue, c Context) error {
+	value, err := Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	attrs[MessageAttributeName] = &sqs.MessageAttributeValue{
+		DataType:    aws.String(messageAttributeDataType),
+		StringValue: aws.String(string(value)),
+	}
+
+	return nil
+}
+
+// FromSQSMessageAttributes restores the evaluation context from the
+// attributes of an SQS message received with the AWS SDK.
+func FromSQSMessageAttributes(attrs map[string]*sqs.MessageAttributeValue) (Context, error) {
+	attr, ok := attrs[MessageAttributeName]
+	if !ok || attr == nil || attr.StringValue == nil {
+		return nil, ErrNoEvaluationContext
+	}
+
+	return Unmarshal([]byte(*attr.StringValue))
+}
+
+// FromSQSEventMessage restores the evaluation context from an SQS message
+// delivered to a Lambda function.
+func FromSQSEventMessage(msg events.SQSMessage) (Context, error) {
+	attr, ok := msg.MessageAttributes[MessageAttributeName]
+	if !ok || attr.StringValue == nil {
+		return nil, ErrNoEvaluationContext
+	}
+
+	return Unmarshal([]byte(*attr.StringValue))
+}
+
+// AddToSNSMessageAttributes serialises the evaluation context into the
+// MessageAttributeName attribute of an SNS message that is about to be
+// published. The attributes are delivered to SQS subscribers as SQS message
+// attributes. The attributes map must not be nil.
+func AddToSNSMessageAttributes(attrs map[string]*sns.MessageAttributeValue, c Context) error {
+	value, err := Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	attrs[MessageAttributeName] = &sns.MessageAttributeValue{
+		DataType:    aws.String(messageAttributeDataType),
+		StringValue: aws.String(string(value)),
+	}
+
+	return nil
+}
+
+// FromSNSEventRecord restores the evaluation context from an SNS message
+// delivered to a Lambda function.
+func FromSNSEventRecord(record events.SNSEventRecord) (Context, error) {
+	attr, ok := record.SNS.MessageAttributes[MessageAttributeName].(map[string]interface{})
+	if !ok {
+		return nil, ErrNoEvaluationContext
+	}
+
+	value, ok := attr["Value"].(string)
+	if !ok {
+		return nil, fmt.Errorf("%w: attribute has no string value", ErrNoEvaluationContext)
+	}
+
+	return Unmarshal([]byte(value))
+}
@@ -0,0 +1,83 @@
+package evaluationcontext_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQSMessageAttributes(t *testing.T) {
+	user := evaluationcontext.NewUser("user-id", evaluationcontext.WithAccountID("account-id"))
+
+	attrs := map[string]*sqs.MessageAttributeValue{}
+	require.NoError(t, evaluationcontext.AddToSQSMessageAttributes(attrs, user))
+	require.Contains(t, attrs, evaluationcontext.MessageAttributeName)
+	assert.Equal(t, "String", *attrs[evaluationcontext.MessageAttributeName].DataType)
+
+	t.Run("restores from SDK message attributes", func(t *testing.T) {
+		restored, err := evaluationcontext.FromSQSMessageAttributes(attrs)
+		require.NoError(t, err)
+		assert.Equal(t, user, restored)
+	})
+
+	t.Run("restores from a Lambda SQS event", func(t *testing.T) {
+		msg := events.SQSMessage{MessageAttributes: map[string]events.SQSMessageAttribute{
+			evaluationcontext.MessageAttributeName: {
+				StringValue: attrs[evaluationcontext.MessageAttributeName].StringValue,
+				DataType:    "String",
+			},
+		}}
+
+		restored, err := evaluationcontext.FromSQSEventMessage(msg)
+		require.NoError(t, err)
+		assert.Equal(t, user, restored)
+	})
+
+	t.Run("returns an error if there is no attribute", func(t *testing.T) {
+		_, err := evaluationcontext.FromSQSMessageAttributes(nil)
+		require.ErrorIs(t, err, evaluationcontext.ErrNoEvaluationContext)
+
+		_, err = evaluationcontext.FromSQSEventMessage(events.SQSMessage{})
+		require.ErrorIs(t, err, evaluationcontext.ErrNoEvaluationContext)
+	})
+}
+
+func TestSNSMessageAttributes(t *testing.T) {
+	user := evaluationcontext.NewAnonymousUser("request-id")
+
+	attrs := map[string]*sns.MessageAttributeValue{}
+	require.NoError(t, evaluationcontext.AddToSNSMessageAttributes(attrs, user))
+	require.Contains(t, attrs, evaluationcontext.MessageAttributeName)
+
+	t.Run("restores from a Lambda SNS event", func(t *testing.T) {
+		// SNS delivers message attributes to Lambda as {"Type": ..., "Value": ...}.
+		var record events.SNSEventRecord
+		require.NoError(t, json.Unmarshal([]byte(`{"Sns": {"MessageAttributes": {
+			"evaluation-context": {"Type": "String", "Value": `+jsonString(t, *attrs[evaluationcontext.MessageAttributeName].StringValue)+`}
+		}}}`), &record))
+
+		restored, err := evaluationcontext.FromSNSEventRecord(record)
+		require.NoError(t, err)
+		assert.Equal(t, user, restored)
+	})
+
+	t.Run("returns an error if there is no attribute", func(t *testing.T) {
+		_, err := evaluationcontext.FromSNSEventRecord(events.SNSEventRecord{})
+		require.ErrorIs(t, err, evaluationcontext.ErrNoEvaluationContext)
+	})
+}
+
+func jsonString(t *testing.T, s string) string {
+	t.Helper()
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	return string(data)
+}
@@ -5,14 +5,18 @@ import (
 	"errors"
 
 	"github.com/cultureamp/ca-go/x/request"
-	"github.com/google/uuid"
 	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
 	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
 )
 
 const (
-	userAttributeAccountID  = "accountID"
-	userAttributeRealUserID = "realUserID"
+	userAttributeAccountID         = "accountID"
+	userAttributeRealUserID        = "realUserID"
+	userAttributeLocale            = "locale"
+	userAttributeTimezone          = "timezone"
+	userAttributeClientApplication = "clientApplication"
+	userAttributeClientVersion     = "clientVersion"
+	userAttributeUserAgent         = "userAgent"
 )
 
 // User is a type of context, representing the identifiers and attributes of
@@ -21,6 +25,7 @@ type User struct {
 	key        string
 	realUserID string
 	accountID  string
+	metadata   request.RequestMetadata
 
 	ldUser lduser.User
 }
@@ -50,14 +55,24 @@ func WithRealUserID(id string) UserOption {
 	}
 }
 
+// WithRequestMetadata configures the user with the locale, time zone, client
+// application and version, source IP and user agent of the request. The source
+// IP is set as the built-in "ip" attribute, and the others as custom
+// attributes. Empty values are omitted.
+func WithRequestMetadata(metadata request.RequestMetadata) UserOption {
+	return func(u *User) {
+		u.metadata = metadata
+	}
+}
+
 // NewAnonymousUser returns a user object suitable for use in unauthenticated
 // requests or requests with no access to user identifiers.
 // Provide a unique session or request identifier as the key if possible. If the
-// key is empty, it will default to 'ANONYMOUS_USER' and percentage rollouts
-// will not be supported.
+// key is empty, a new key is generated with request.NewID, so the user will
+// fall into a different percentage rollout bucket on each call.
 func NewAnonymousUser(key string) User {
 	if key == "" {
-		key = uuid.NewString()
+		key = request.NewID()
 	}
 
 	u := User{
@@ -90,23 +105,48 @@ func NewUser(userID string, opts ...UserOption) User {
 	userBuilder.Custom(
 		userAttributeRealUserID,
 		ldvalue.String(u.realUserID))
+	addRequestMetadata(userBuilder, u.metadata)
 	u.ldUser = userBuilder.Build()
 
 	return *u
 }
 
 // UserFromContext extracts the effective user aggregate ID, real user aggregate
-// ID, and account aggregate ID from the context. These values are used to
-// create a new User object. An error is returned if user identifiers are not
-// present in the context.
+// ID, and account aggregate ID from the context, along with any
+// RequestMetadata. These values are used to create a new User object. An error
+// is returned if user identifiers are not present in the context.
 func UserFromContext(ctx context.Context) (User, error) {
 	authenticatedUser, ok := request.AuthenticatedUserFromContext(ctx)
 	if !ok {
 		return User{}, errors.New("no AuthenticatedUser in supplied context")
 	}
 
-	return NewUser(
-		authenticatedUser.UserID,
+	opts := []UserOption{
 		WithAccountID(authenticatedUser.CustomerAccountID),
-		WithRealUserID(authenticatedUser.RealUserID)), nil
+		WithRealUserID(authenticatedUser.RealUserID),
+	}
+
+	if metadata, ok := request.RequestMetadataFromContext(ctx); ok {
+		opts = append(opts, WithRequestMetadata(metadata))
+	}
+
+	return NewUser(authenticatedUser.UserID, opts...), nil
+}
+
+func addRequestMetadata(userBuilder lduser.UserBuilder, metadata request.RequestMetadata) {
+	if metadata.SourceIP != "" {
+		userBuilder.IP(metadata.SourceIP)
+	}
+
+	for name, value := range map[string]string{
+		userAttributeLocale:            metadata.Locale,
+		userAttributeTimezone:          metadata.Timezone,
+		userAttributeClientApplication: metadata.ClientApplication,
+		userAttributeClientVersion:     metadata.ClientVersion,
+		userAttributeUserAgent:         metadata.UserAgent,
+	} {
+		if value != "" {
+			userBuilder.Custom(name, ldvalue.String(value))
+		}
+	}
 }
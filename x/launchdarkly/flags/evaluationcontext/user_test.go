@@ -17,6 +17,14 @@ func TestNewUser(t *testing.T) {
 		assert.True(t, ldUser.GetAnonymous())
 	})
 
+	t.Run("generates an anonymous user key with the configured ID generator", func(t *testing.T) {
+		t.Cleanup(func() { request.SetIDGenerator(nil) })
+		request.SetIDGenerator(request.IDGeneratorFunc(func() string { return "generated-id" }))
+
+		user := evaluationcontext.NewAnonymousUser("")
+		assert.Equal(t, "generated-id", user.ToLDUser().GetKey())
+	})
+
 	t.Run("can create an anonymous user with session/request key", func(t *testing.T) {
 		user := evaluationcontext.NewAnonymousUser("my-request-id")
 		assertUserAttributes(t, user, "my-request-id", "", "")
@@ -47,6 +55,45 @@ func TestNewUser(t *testing.T) {
 		require.NoError(t, err)
 		assertUserAttributes(t, flagsUser, "789", "456", "123")
 	})
+
+	t.Run("can create a user with request metadata", func(t *testing.T) {
+		metadata := request.RequestMetadata{
+			Locale:            "en-AU",
+			Timezone:          "Australia/Melbourne",
+			ClientApplication: "performance-ui",
+			ClientVersion:     "1.2.3",
+			SourceIP:          "203.0.113.7",
+			UserAgent:         "Mozilla/5.0",
+		}
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+			CustomerAccountID: "123",
+			UserID:            "789",
+		})
+		ctx = request.ContextWithRequestMetadata(ctx, metadata)
+
+		flagsUser, err := evaluationcontext.UserFromContext(ctx)
+		require.NoError(t, err)
+		assertUserAttributes(t, flagsUser, "789", "", "123")
+
+		ldUser := flagsUser.ToLDUser()
+		assert.Equal(t, "203.0.113.7", ldUser.GetIP().StringValue())
+		assert.Equal(t, "en-AU", ldUser.GetAttribute("locale").StringValue())
+		assert.Equal(t, "Australia/Melbourne", ldUser.GetAttribute("timezone").StringValue())
+		assert.Equal(t, "performance-ui", ldUser.GetAttribute("clientApplication").StringValue())
+		assert.Equal(t, "1.2.3", ldUser.GetAttribute("clientVersion").StringValue())
+		assert.Equal(t, "Mozilla/5.0", ldUser.GetAttribute("userAgent").StringValue())
+	})
+
+	t.Run("omits empty request metadata", func(t *testing.T) {
+		user := evaluationcontext.NewUser(
+			"789",
+			evaluationcontext.WithRequestMetadata(request.RequestMetadata{Locale: "fr"}))
+
+		ldUser := user.ToLDUser()
+		assert.Equal(t, "fr", ldUser.GetAttribute("locale").StringValue())
+		assert.True(t, ldUser.GetAttribute("timezone").IsNull())
+		assert.False(t, ldUser.GetIP().IsDefined())
+	})
 }
 
 func assertUserAttributes(t *testing.T, user evaluationcontext.User, userID, realUserID, accountID string) {
@@ -0,0 +1,26 @@
+// Package flagfile reads, writes and compares flag data files in the format
+// used by the LaunchDarkly file data source, such as the .ld-flags.json file
+// read by the flags package in test mode.
+//
+// A file can be produced from the flags registered with flags.Register:
+//   file, err := flagfile.FromDefinitions(flags.Definitions())
+//   if err != nil {
+//     // handle invalid flag defaults
+//   }
+//
+//   err = file.WriteFile(".ld-flags.json")
+//
+// Because the registry is populated by the packages of your service, this
+// must be done from a program that imports those packages. The ldflags
+// command does this with its -registry flag.
+//
+// A file can also be produced from flag data exported from LaunchDarkly. Parse
+// accepts the flag data served by the LD Relay Proxy and the SDK endpoints
+// (`{"flags": {...}}`), the output of the LaunchDarkly REST API's list flags
+// endpoint (`{"items": [...]}`), and existing flag data files. The value for
+// each flag is the value served when targeting is on and no rules match.
+//
+// Compare reports the flags that differ between a local file and a reference
+// export. The ldflags command (in ../cmd/ldflags) wraps these functions for
+// use on the command line.
+package flagfile
@@ -0,0 +1,315 @@
+package flagfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+)
+
+// errNoOffVariation is returned for flags that are off and have no off
+// variation, which serve the caller's fallback value.
+var errNoOffVariation = errors.New("flag is off and has no off variation")
+
+// File holds flag values in the format read by the LaunchDarkly file data
+// source.
+type File struct {
+	// FlagValues maps flag names to the JSON-encoded value of each flag.
+	FlagValues map[string]json.RawMessage `json:"flagValues"`
+}
+
+// document covers the supported input formats.
+type document struct {
+	// FlagValues is the simplified format of the file data source.
+	FlagValues map[string]json.RawMessage `json:"flagValues"`
+
+	// Flags holds complete flag configurations, as served by the Relay
+	// Proxy and SDK endpoints, and supported by the file data source.
+	Flags map[string]sdkFlag `json:"flags"`
+
+	// Items holds flags as returned by the LaunchDarkly REST API.
+	Items []apiFlag `json:"items"`
+}
+
+type sdkFlag struct {
+	On           bool              `json:"on"`
+	Variations   []json.RawMessage `json:"variations"`
+	OffVariation *int              `json:"offVariation"`
+	Fallthrough  struct {
+		Variation *int `json:"variation"`
+		Rollout   *struct {
+			Variations []struct {
+				Variation int `json:"variation"`
+				Weight    int `json:"weight"`
+			} `json:"variations"`
+		} `json:"rollout"`
+	} `json:"fallthrough"`
+}
+
+type apiFlag struct {
+	Key        string `json:"key"`
+	Variations []struct {
+		Value json.RawMessage `json:"value"`
+	} `json:"variations"`
+	Defaults *struct {
+		OnVariation int `json:"onVariation"`
+	} `json:"defaults"`
+}
+
+// FromDefinitions returns a File containing the default value of each of the
+// given flag definitions.
+func FromDefinitions(defs []flags.Definition) (File, error) {
+	f := File{FlagValues: make(map[string]json.RawMessage, len(defs))}
+
+	for _, def := range defs {
+		value, err := json.Marshal(def.Default)
+		if err != nil {
+			return File{}, fmt.Errorf("marshal default value of %s: %w", def.Name, err)
+		}
+
+		f.FlagValues[string(def.Name)] = value
+	}
+
+	return f, nil
+}
+
+type parseConfig struct {
+	defaults map[string]interface{}
+}
+
+// ParseOption is a function type that can be provided to Parse and ReadFile
+// to configure how flag data is read.
+type ParseOption func(c *parseConfig)
+
+// WithDefaults supplies the flag definitions whose default values are used for
+// flags that are off and have no off variation. LaunchDarkly serves the
+// fallback value supplied by the caller for these flags, so without a
+// definition they are left out of the file.
+func WithDefaults(defs []flags.Definition) ParseOption {
+	return func(c *parseConfig) {
+		for _, def := range defs {
+			c.defaults[string(def.Name)] = def.Default
+		}
+	}
+}
+
+// Parse reads flag data from r. See the package documentation for the
+// supported formats.
+func Parse(r io.Reader, opts ...ParseOption) (File, error) {
+	cfg := &parseConfig{defaults: map[string]interface{}{}}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var doc document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return File{}, fmt.Errorf("parse flag data: %w", err)
+	}
+
+	f := File{FlagValues: map[string]json.RawMessage{}}
+
+	for key, value := range doc.FlagValues {
+		f.FlagValues[key] = value
+	}
+
+	for key, flag := range doc.Flags {
+		value, err := flag.value()
+		if errors.Is(err, errNoOffVariation) {
+			def, ok := cfg.defaults[key]
+			if !ok {
+				continue
+			}
+
+			value, err = json.Marshal(def)
+		}
+
+		if err != nil {
+			return File{}, fmt.Errorf("flag %s: %w", key, err)
+		}
+
+		f.FlagValues[key] = value
+	}
+
+	for _, flag := range doc.Items {
+		value, err := flag.value()
+		if err != nil {
+			return File{}, fmt.Errorf("flag %s: %w", flag.Key, err)
+		}
+
+		f.FlagValues[flag.Key] = value
+	}
+
+	return f, nil
+}
+
+// ReadFile reads flag data from the named file. See Parse.
+func ReadFile(filename string, opts ...ParseOption) (File, error) {
+	// #nosec G304 -- reading a file named by the caller is the intent
+	r, err := os.Open(filename)
+	if err != nil {
+		return File{}, fmt.Errorf("open flag data: %w", err)
+	}
+	defer r.Close()
+
+	return Parse(r, opts...)
+}
+
+// Names returns the names of the flags in the file, sorted.
+func (f File) Names() []string {
+	names := make([]string, 0, len(f.FlagValues))
+	for name := range f.FlagValues {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// Write writes the file to w as indented JSON, with flags sorted by name.
+func (f File) Write(w io.Writer) error {
+	if f.FlagValues == nil {
+		f.FlagValues = map[string]json.RawMessage{}
+	}
+
+	contents, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal flag data: %w", err)
+	}
+
+	if _, err := w.Write(append(contents, '\n')); err != nil {
+		return fmt.Errorf("write flag data: %w", err)
+	}
+
+	return nil
+}
+
+// WriteFile writes the file to the named file, replacing it if it exists.
+func (f File) WriteFile(filename string) error {
+	var buf bytes.Buffer
+	if err := f.Write(&buf); err != nil {
+		return err
+	}
+
+	// #nosec G306 -- flag data files are not sensitive
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write flag data: %w", err)
+	}
+
+	return nil
+}
+
+// Diff describes the differences between two files.
+type Diff struct {
+	// Missing are the flags in the reference that are not in the local file.
+	Missing []string
+
+	// Extra are the flags in the local file that are not in the reference.
+	Extra []string
+
+	// Changed are the flags in both files with different values.
+	Changed []string
+}
+
+// Empty returns whether the files were equivalent.
+func (d Diff) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Changed) == 0
+}
+
+// Compare returns the differences between a local file and a reference file,
+// for example an export from LaunchDarkly. Flag names in each part of the
+// result are sorted.
+func Compare(local, reference File) Diff {
+	var d Diff
+
+	for _, name := range reference.Names() {
+		localValue, ok := local.FlagValues[name]
+		if !ok {
+			d.Missing = append(d.Missing, name)
+			continue
+		}
+
+		if !jsonEqual(localValue, reference.FlagValues[name]) {
+			d.Changed = append(d.Changed, name)
+		}
+	}
+
+	for _, name := range local.Names() {
+		if _, ok := reference.FlagValues[name]; !ok {
+			d.Extra = append(d.Extra, name)
+		}
+	}
+
+	return d
+}
+
+// value returns the value the flag serves when no rules or targets match.
+func (f sdkFlag) value() (json.RawMessage, error) {
+	if !f.On {
+		if f.OffVariation == nil {
+			return nil, errNoOffVariation
+		}
+
+		return variation(f.Variations, *f.OffVariation)
+	}
+
+	if f.Fallthrough.Variation != nil {
+		return variation(f.Variations, *f.Fallthrough.Variation)
+	}
+
+	// For a percentage rollout, use the variation with the largest weight.
+	if f.Fallthrough.Rollout != nil && len(f.Fallthrough.Rollout.Variations) > 0 {
+		largest := f.Fallthrough.Rollout.Variations[0]
+		for _, v := range f.Fallthrough.Rollout.Variations[1:] {
+			if v.Weight > largest.Weight {
+				largest = v
+			}
+		}
+
+		return variation(f.Variations, largest.Variation)
+	}
+
+	return nil, errors.New("no fallthrough variation or rollout")
+}
+
+func (f apiFlag) value() (json.RawMessage, error) {
+	if f.Defaults == nil {
+		return nil, errors.New("no default variations")
+	}
+
+	values := make([]json.RawMessage, 0, len(f.Variations))
+	for _, v := range f.Variations {
+		values = append(values, v.Value)
+	}
+
+	return variation(values, f.Defaults.OnVariation)
+}
+
+func variation(variations []json.RawMessage, index int) (json.RawMessage, error) {
+	if index < 0 || index >= len(variations) {
+		return nil, fmt.Errorf("variation %d out of range", index)
+	}
+
+	return variations[index], nil
+}
+
+// jsonEqual returns whether two JSON values are equivalent, ignoring
+// formatting and the order of object keys.
+func jsonEqual(a, b json.RawMessage) bool {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,169 @@
+package flagfile_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const relayExportJSON = `
+{
+	"flags": {
+		"on-flag": {
+			"key": "on-flag",
+			"on": true,
+			"variations": [true, false],
+			"offVariation": 1,
+			"fallthrough": {"variation": 0}
+		},
+		"off-flag": {
+			"key": "off-flag",
+			"on": false,
+			"variations": ["a", "b"],
+			"offVariation": 1,
+			"fallthrough": {"variation": 0}
+		},
+		"rollout-flag": {
+			"key": "rollout-flag",
+			"on": true,
+			"variations": [1, 2, 3],
+			"fallthrough": {"rollout": {"variations": [
+				{"variation": 0, "weight": 20000},
+				{"variation": 2, "weight": 80000}
+			]}}
+		}
+	},
+	"segments": {}
+}
+`
+
+const apiExportJSON = `
+{
+	"items": [
+		{
+			"key": "api-flag",
+			"variations": [{"value": {"limit": 10}}, {"value": {"limit": 0}}],
+			"defaults": {"onVariation": 0, "offVariation": 1}
+		}
+	]
+}
+`
+
+func TestParse(t *testing.T) {
+	t.Run("parses Relay Proxy and SDK flag data", func(t *testing.T) {
+		f, err := flagfile.Parse(strings.NewReader(relayExportJSON))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"off-flag", "on-flag", "rollout-flag"}, f.Names())
+		assert.JSONEq(t, `true`, string(f.FlagValues["on-flag"]))
+		assert.JSONEq(t, `"b"`, string(f.FlagValues["off-flag"]))
+		assert.JSONEq(t, `3`, string(f.FlagValues["rollout-flag"]))
+	})
+
+	t.Run("parses REST API flag data", func(t *testing.T) {
+		f, err := flagfile.Parse(strings.NewReader(apiExportJSON))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"api-flag"}, f.Names())
+		assert.JSONEq(t, `{"limit": 10}`, string(f.FlagValues["api-flag"]))
+	})
+
+	t.Run("parses flag data files", func(t *testing.T) {
+		f, err := flagfile.Parse(strings.NewReader(`{"flagValues": {"my-flag": "value"}}`))
+		require.NoError(t, err)
+
+		assert.JSONEq(t, `"value"`, string(f.FlagValues["my-flag"]))
+	})
+
+	t.Run("uses the definition's default for off flags with no off variation", func(t *testing.T) {
+		const data = `{"flags": {
+			"defined-flag": {"on": false, "variations": [true, false]},
+			"undefined-flag": {"on": false, "variations": [true, false]}
+		}}`
+
+		f, err := flagfile.Parse(strings.NewReader(data), flagfile.WithDefaults([]flags.Definition{
+			{Name: "defined-flag", Default: false},
+		}))
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"defined-flag"}, f.Names())
+		assert.JSONEq(t, `false`, string(f.FlagValues["defined-flag"]))
+
+		f, err = flagfile.Parse(strings.NewReader(data))
+		require.NoError(t, err)
+		assert.Empty(t, f.Names())
+	})
+
+	t.Run("errors on invalid flag data", func(t *testing.T) {
+		_, err := flagfile.Parse(strings.NewReader(`{"flags": {"bad-flag": {"on": true, "variations": [true], "fallthrough": {"variation": 3}}}}`))
+		require.EqualError(t, err, "flag bad-flag: variation 3 out of range")
+
+		_, err = flagfile.Parse(strings.NewReader(`not json`))
+		require.Error(t, err)
+	})
+}
+
+func TestFromDefinitions(t *testing.T) {
+	f, err := flagfile.FromDefinitions([]flags.Definition{
+		{Name: "bool-flag", Default: true},
+		{Name: "string-flag", Default: "value"},
+		{Name: "json-flag", Default: map[string]int{"limit": 10}},
+	})
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	assert.Equal(t, `{
+  "flagValues": {
+    "bool-flag": true,
+    "json-flag": {
+      "limit": 10
+    },
+    "string-flag": "value"
+  }
+}
+`, buf.String())
+
+	_, err = flagfile.FromDefinitions([]flags.Definition{{Name: "bad-flag", Default: func() {}}})
+	require.Error(t, err)
+}
+
+func TestWriteFileRoundTrip(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), ".ld-flags.json")
+
+	f, err := flagfile.Parse(strings.NewReader(relayExportJSON))
+	require.NoError(t, err)
+	require.NoError(t, f.WriteFile(filename))
+
+	read, err := flagfile.ReadFile(filename)
+	require.NoError(t, err)
+	assert.True(t, flagfile.Compare(read, f).Empty())
+
+	_, err = flagfile.ReadFile(filepath.Join(t.TempDir(), "missing.json"))
+	require.Error(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	local, err := flagfile.Parse(strings.NewReader(`{"flagValues": {
+		"on-flag": true,
+		"off-flag": "a",
+		"local-only-flag": 1
+	}}`))
+	require.NoError(t, err)
+
+	reference, err := flagfile.Parse(strings.NewReader(relayExportJSON))
+	require.NoError(t, err)
+
+	diff := flagfile.Compare(local, reference)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []string{"rollout-flag"}, diff.Missing)
+	assert.Equal(t, []string{"local-only-flag"}, diff.Extra)
+	assert.Equal(t, []string{"off-flag"}, diff.Changed)
+}
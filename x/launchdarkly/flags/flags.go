@@ -2,41 +2,85 @@ package flags
 
 import (
 	"fmt"
+	"sync"
 )
 
-var flagsClient *Client
+// defaultClientName is the registry name of the managed singleton configured
+// by Configure. It is configured from the top-level SDK key in
+// LAUNCHDARKLY_CONFIGURATION.
+const defaultClientName = ""
+
+var (
+	clientsMu sync.RWMutex
+	clients   = map[string]*Client{}
+)
 
 // FlagName establishes a type for flag names.
 type FlagName string
 
 // Configure configures the client as a managed singleton.
 func Configure(opts ...ConfigOption) error {
-	c, err := NewClient(opts...)
-	if err != nil {
-		return fmt.Errorf("configure client: %w", err)
-	}
-
-	flagsClient = c
-	return nil
+	return ConfigureClient(defaultClientName, opts...)
 }
 
 // Connect attempts to connect the managed singleton to LaunchDarkly. An error
 // is returned if the singleton is not yet configured, a connection has already
 // been established, or a connection error occurs.
 func Connect() error {
-	if flagsClient == nil {
-		return errClientNotConfigured
-	}
-
-	return flagsClient.Connect()
+	return ConnectClient(defaultClientName)
 }
 
 // GetDefaultClient returns the managed singleton client. An error is returned
 // if the client is not yet configured.
 func GetDefaultClient() (*Client, error) {
-	if flagsClient == nil {
-		return nil, errClientNotConfigured
+	return GetClient(defaultClientName)
+}
+
+// ConfigureClient configures a managed client registered under the given name.
+// The client is configured from the entry with the same name in the "clients"
+// section of LAUNCHDARKLY_CONFIGURATION, allowing flags from several
+// LaunchDarkly projects to be queried side by side. Each named client is
+// configured independently, so different clients may use different modes.
+// Configuring a name that is already registered replaces the existing client.
+func ConfigureClient(name string, opts ...ConfigOption) error {
+	c, err := NewClient(append(append([]ConfigOption(nil), opts...), WithClientName(name))...)
+	if err != nil {
+		return fmt.Errorf("configure client: %w", err)
+	}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	clients[name] = c
+	return nil
+}
+
+// ConnectClient attempts to connect the managed client with the given name to
+// LaunchDarkly. An error is returned if the client is not yet configured, a
+// connection has already been established, or a connection error occurs.
+func ConnectClient(name string) error {
+	c, err := GetClient(name)
+	if err != nil {
+		return err
+	}
+
+	return c.Connect()
+}
+
+// GetClient returns the managed client registered under the given name. An
+// error is returned if the client is not yet configured.
+func GetClient(name string) (*Client, error) {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+
+	c, ok := clients[name]
+	if !ok {
+		if name == defaultClientName {
+			return nil, errClientNotConfigured
+		}
+
+		return nil, fmt.Errorf("%w: %q", errClientNotConfigured, name)
 	}
 
-	return flagsClient, nil
+	return c, nil
 }
@@ -2,11 +2,37 @@ package flags
 
 import (
 	"os"
+	"reflect"
 	"testing"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+const validMultiClientConfigJSON = `
+{
+    "sdkKey":"super-secret-key",
+    "options":{
+        "proxyMode":{
+            "url":"https://relay-proxy.cultureamp.net"
+        }
+    },
+    "clients":{
+        "other-project":{
+            "sdkKey":"other-super-secret-key",
+            "options":{
+                "daemonMode":{
+                    "DynamoTableName":"other-dynamo-table"
+                },
+                "proxyMode":{
+                    "url":"https://other-relay-proxy.cultureamp.net"
+                }
+            }
+        }
+    }
+}
+`
+
 func TestSingletonInitialisation(t *testing.T) {
 	t.Run("does not error if SDK key supplied as env var", func(t *testing.T) {
 		os.Setenv(configurationEnvVar, validConfigJSON)
@@ -15,3 +41,68 @@ func TestSingletonInitialisation(t *testing.T) {
 		require.NoError(t, err)
 	})
 }
+
+func TestNamedClients(t *testing.T) {
+	t.Run("configures named clients independently of the default client", func(t *testing.T) {
+		os.Setenv(configurationEnvVar, validMultiClientConfigJSON)
+		defer os.Unsetenv(configurationEnvVar)
+
+		require.NoError(t, Configure())
+		require.NoError(t, ConfigureClient("other-project", WithLambdaMode(nil)))
+
+		defaultClient, err := GetDefaultClient()
+		require.NoError(t, err)
+		assert.Equal(t, "super-secret-key", defaultClient.sdkKey)
+		assert.Equal(t, modeProxy, defaultClient.mode)
+		assert.Equal(t, "https://relay-proxy.cultureamp.net", defaultClient.wrappedConfig.ServiceEndpoints.Streaming)
+
+		otherClient, err := GetClient("other-project")
+		require.NoError(t, err)
+		assert.Equal(t, "other-super-secret-key", otherClient.sdkKey)
+		assert.Equal(t, modeLambda, otherClient.mode)
+		assert.NotSame(t, defaultClient, otherClient)
+	})
+
+	t.Run("configures named clients in test mode with separate data sources", func(t *testing.T) {
+		require.NoError(t, ConfigureClient("project-a"))
+		require.NoError(t, ConfigureClient("project-b"))
+
+		clientA, err := GetClient("project-a")
+		require.NoError(t, err)
+		clientB, err := GetClient("project-b")
+		require.NoError(t, err)
+
+		tdA, err := clientA.TestDataSource()
+		require.NoError(t, err)
+		tdB, err := clientB.TestDataSource()
+		require.NoError(t, err)
+		assert.NotSame(t, tdA, tdB)
+	})
+
+	t.Run("errors if the named client is missing from the environment variable", func(t *testing.T) {
+		os.Setenv(configurationEnvVar, validMultiClientConfigJSON)
+		defer os.Unsetenv(configurationEnvVar)
+
+		err := ConfigureClient("missing-project")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `did not contain configuration for client "missing-project"`)
+	})
+
+	t.Run("does not modify the caller's options", func(t *testing.T) {
+		opts := make([]ConfigOption, 1, 2)
+		opts[0] = WithLambdaMode(nil)
+		sentinel := WithLambdaMode(nil)
+		opts = append(opts, sentinel)[:1]
+
+		require.NoError(t, ConfigureClient("project-c", opts...))
+
+		assert.Equal(t, reflect.ValueOf(sentinel).Pointer(), reflect.ValueOf(opts[:2][1]).Pointer())
+	})
+
+	t.Run("errors if the named client is not configured", func(t *testing.T) {
+		_, err := GetClient("not-configured")
+		require.ErrorIs(t, err, errClientNotConfigured)
+
+		require.ErrorIs(t, ConnectClient("not-configured"), errClientNotConfigured)
+	})
+}
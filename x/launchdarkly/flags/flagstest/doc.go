@@ -0,0 +1,29 @@
+// Package flagstest provides helpers for testing code that queries feature
+// flags with the flags package.
+//
+// NewTestClient returns a flags client in test mode that is isolated from any
+// .ld-flags.json file in the working directory, and is shut down
+// automatically when the test completes. Flag values are set with fluent
+// helpers:
+//   func TestMyHandler(t *testing.T) {
+//     client := flagstest.NewTestClient(t).
+//       SetBool("my-kill-switch", false).
+//       SetString("my-string-flag", "value").
+//       SetBoolForUser("my-beta-flag", "user-id", true)
+//
+//     // use client (or client.Client) in place of a *flags.Client
+//   }
+//
+// Every evaluation made through the client is recorded, so tests can assert on
+// the flags a code path checked, the user it was checked for, and the result:
+//   client.AssertEvaluatedForUser(t, "my-kill-switch", "user-id")
+//   client.AssertEvaluatedWithResult(t, "my-kill-switch", false)
+//
+// Recorded evaluations can also be inspected directly with Evaluations() and
+// EvaluationsOf(). A Recorder can be added to any other flags client with
+// flags.WithHooks.
+//
+// Values for users that have not been targeted are those set for all users.
+// If a flag has only been set for specific users, other users receive the
+// zero value of the flag's type.
+package flagstest
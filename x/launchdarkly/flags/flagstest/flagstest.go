@@ -0,0 +1,187 @@
+package flagstest
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+	"gopkg.in/launchdarkly/go-server-sdk.v5/testhelpers/ldtestdata"
+)
+
+// TestClient is a flags client in test mode, with helpers to set the values
+// of flags. It embeds the *flags.Client, so can be queried directly, and a
+// *Recorder that captures every evaluation made through the client.
+type TestClient struct {
+	*flags.Client
+	*Recorder
+
+	datasource *ldtestdata.TestDataSource
+
+	mu    sync.Mutex
+	flags map[flags.FlagName]*flagState
+}
+
+// flagState is the set of values configured for a single flag.
+type flagState struct {
+	variations       []ldvalue.Value
+	fallthroughIndex int
+	targets          map[string]int
+}
+
+// NewTestClient returns a connected client in test mode, backed by a dynamic
+// test data source. The client ignores any .ld-flags.json file in the working
+// directory, so flag values are only those set through the TestClient. The
+// client is shut down when the test and its subtests complete.
+//
+// Additional options (such as WithHooks) can be supplied; test mode options
+// are always applied last. Evaluations are recorded after any hooks supplied
+// in the options have run.
+func NewTestClient(t testing.TB, opts ...flags.ConfigOption) *TestClient {
+	t.Helper()
+
+	recorder := NewRecorder()
+	opts = append(append([]flags.ConfigOption(nil), opts...),
+		flags.WithHooks(recorder),
+		flags.WithTestMode(&flags.TestModeConfig{
+			IgnoreDefaultFlagsFile: true,
+		}))
+
+	client, err := flags.NewClient(opts...)
+	if err != nil {
+		t.Fatalf("create flags client: %v", err)
+	}
+
+	if err := client.Connect(); err != nil {
+		t.Fatalf("connect flags client: %v", err)
+	}
+
+	t.Cleanup(func() {
+		_ = client.Shutdown()
+	})
+
+	datasource, err := client.TestDataSource()
+	if err != nil {
+		t.Fatalf("get test data source: %v", err)
+	}
+
+	return &TestClient{
+		Client:     client,
+		Recorder:   recorder,
+		datasource: datasource,
+		flags:      map[flags.FlagName]*flagState{},
+	}
+}
+
+// SetBool sets the value of a boolean flag for all users.
+func (c *TestClient) SetBool(flag flags.FlagName, value bool) *TestClient {
+	return c.set(flag, ldvalue.Bool(value), ldvalue.Bool(false))
+}
+
+// SetString sets the value of a string flag for all users.
+func (c *TestClient) SetString(flag flags.FlagName, value string) *TestClient {
+	return c.set(flag, ldvalue.String(value), ldvalue.String(""))
+}
+
+// SetInt sets the value of an integer flag for all users.
+func (c *TestClient) SetInt(flag flags.FlagName, value int) *TestClient {
+	return c.set(flag, ldvalue.Int(value), ldvalue.Int(0))
+}
+
+// SetJSON sets the value of a JSON flag for all users. The value may be any
+// value that can be marshalled to JSON.
+func (c *TestClient) SetJSON(flag flags.FlagName, value interface{}) *TestClient {
+	return c.set(flag, ldvalue.CopyArbitraryValue(value), ldvalue.Null())
+}
+
+// SetBoolForUser sets the value of a boolean flag for the user with the
+// given ID, overriding the value set for all users.
+func (c *TestClient) SetBoolForUser(flag flags.FlagName, userID string, value bool) *TestClient {
+	return c.setForUser(flag, userID, ldvalue.Bool(value), ldvalue.Bool(false))
+}
+
+// SetStringForUser sets the value of a string flag for the user with the
+// given ID, overriding the value set for all users.
+func (c *TestClient) SetStringForUser(flag flags.FlagName, userID string, value string) *TestClient {
+	return c.setForUser(flag, userID, ldvalue.String(value), ldvalue.String(""))
+}
+
+// SetIntForUser sets the value of an integer flag for the user with the
+// given ID, overriding the value set for all users.
+func (c *TestClient) SetIntForUser(flag flags.FlagName, userID string, value int) *TestClient {
+	return c.setForUser(flag, userID, ldvalue.Int(value), ldvalue.Int(0))
+}
+
+// SetJSONForUser sets the value of a JSON flag for the user with the given
+// ID, overriding the value set for all users.
+func (c *TestClient) SetJSONForUser(flag flags.FlagName, userID string, value interface{}) *TestClient {
+	return c.setForUser(flag, userID, ldvalue.CopyArbitraryValue(value), ldvalue.Null())
+}
+
+func (c *TestClient) set(flag flags.FlagName, value ldvalue.Value, zero ldvalue.Value) *TestClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state(flag, zero)
+	state.fallthroughIndex = state.variation(value)
+	c.update(flag, state)
+
+	return c
+}
+
+func (c *TestClient) setForUser(flag flags.FlagName, userID string, value ldvalue.Value, zero ldvalue.Value) *TestClient {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	state := c.state(flag, zero)
+	state.targets[userID] = state.variation(value)
+	c.update(flag, state)
+
+	return c
+}
+
+// state returns the existing state of the flag, or a new state serving the
+// zero value to all users.
+func (c *TestClient) state(flag flags.FlagName, zero ldvalue.Value) *flagState {
+	if state, ok := c.flags[flag]; ok {
+		return state
+	}
+
+	state := &flagState{
+		variations: []ldvalue.Value{zero},
+		targets:    map[string]int{},
+	}
+	c.flags[flag] = state
+
+	return state
+}
+
+// update replaces the flag in the test data source with its current state.
+func (c *TestClient) update(flag flags.FlagName, state *flagState) {
+	builder := c.datasource.Flag(string(flag)).
+		Variations(state.variations...).
+		ClearRules().
+		ClearUserTargets().
+		On(true).
+		FallthroughVariationIndex(state.fallthroughIndex).
+		OffVariationIndex(state.fallthroughIndex)
+
+	for userID, variation := range state.targets {
+		builder.VariationIndexForUser(userID, variation)
+	}
+
+	c.datasource.Update(builder)
+}
+
+// variation returns the index of the given value in the flag's variations,
+// adding it if necessary.
+func (s *flagState) variation(value ldvalue.Value) int {
+	for i, v := range s.variations {
+		if v.Equal(value) {
+			return i
+		}
+	}
+
+	s.variations = append(s.variations, value)
+	return len(s.variations) - 1
+}
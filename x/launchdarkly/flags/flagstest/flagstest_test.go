@@ -0,0 +1,147 @@
+package flagstest_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagstest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+	ld "gopkg.in/launchdarkly/go-server-sdk.v5"
+)
+
+func TestNewTestClient(t *testing.T) {
+	user := evaluationcontext.NewUser("user-id")
+	otherUser := evaluationcontext.NewUser("other-user-id")
+
+	t.Run("sets flag values for all users", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetBool("bool-flag", true).
+			SetString("string-flag", "value").
+			SetInt("int-flag", 3)
+
+		boolVal, err := client.QueryBoolWithEvaluationContext("bool-flag", user, false)
+		require.NoError(t, err)
+		assert.True(t, boolVal)
+
+		stringVal, err := client.QueryStringWithEvaluationContext("string-flag", user, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "value", stringVal)
+
+		intVal, err := client.QueryIntWithEvaluationContext("int-flag", user, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 3, intVal)
+	})
+
+	t.Run("sets JSON flag values", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetJSON("json-flag", map[string]interface{}{"limit": 10})
+
+		ldClient, ok := client.RawClient().(*ld.LDClient)
+		require.True(t, ok)
+
+		val, err := ldClient.JSONVariation("json-flag", user.ToLDUser(), ldvalue.Null())
+		require.NoError(t, err)
+		assert.Equal(t, `{"limit":10}`, val.JSONString())
+	})
+
+	t.Run("replaces values that were set earlier", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetString("string-flag", "first").
+			SetString("string-flag", "second")
+
+		val, err := client.QueryStringWithEvaluationContext("string-flag", user, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "second", val)
+	})
+
+	t.Run("sets flag values for targeted users", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetBool("bool-flag", false).
+			SetBoolForUser("bool-flag", "user-id", true).
+			SetStringForUser("string-flag", "user-id", "targeted").
+			SetIntForUser("int-flag", "other-user-id", 5)
+
+		boolVal, err := client.QueryBoolWithEvaluationContext("bool-flag", user, false)
+		require.NoError(t, err)
+		assert.True(t, boolVal)
+
+		boolVal, err = client.QueryBoolWithEvaluationContext("bool-flag", otherUser, true)
+		require.NoError(t, err)
+		assert.False(t, boolVal)
+
+		stringVal, err := client.QueryStringWithEvaluationContext("string-flag", user, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "targeted", stringVal)
+
+		// users that aren't targeted receive the zero value
+		stringVal, err = client.QueryStringWithEvaluationContext("string-flag", otherUser, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "", stringVal)
+
+		intVal, err := client.QueryIntWithEvaluationContext("int-flag", otherUser, 0)
+		require.NoError(t, err)
+		assert.Equal(t, 5, intVal)
+	})
+
+	t.Run("isolates clients from each other", func(t *testing.T) {
+		first := flagstest.NewTestClient(t).SetBool("bool-flag", true)
+		second := flagstest.NewTestClient(t)
+
+		val, err := first.QueryBoolWithEvaluationContext("bool-flag", user, false)
+		require.NoError(t, err)
+		assert.True(t, val)
+
+		_, err = second.QueryBoolWithEvaluationContext("bool-flag", user, false)
+		require.Error(t, err, "flag should not exist in the second client")
+	})
+
+	t.Run("ignores .ld-flags.json in the working directory", func(t *testing.T) {
+		// #nosec G306
+		require.NoError(t, os.WriteFile(".ld-flags.json", []byte(`{"flagValues":{"bool-flag":true}}`), 0666))
+		defer func() {
+			require.NoError(t, os.Remove(".ld-flags.json"))
+		}()
+
+		client := flagstest.NewTestClient(t)
+
+		_, err := client.QueryBoolWithEvaluationContext("bool-flag", user, false)
+		require.Error(t, err, "flag should not be sourced from .ld-flags.json")
+
+		td, err := client.TestDataSource()
+		require.NoError(t, err)
+		assert.NotNil(t, td)
+	})
+}
+
+func ExampleRecorder() {
+	recorder := flagstest.NewRecorder()
+
+	client, err := flags.NewClient(
+		flags.WithHooks(recorder),
+		flags.WithTestMode(&flags.TestModeConfig{IgnoreDefaultFlagsFile: true}))
+	if err != nil {
+		panic(err)
+	}
+
+	if err := client.Connect(); err != nil {
+		panic(err)
+	}
+	defer client.Shutdown()
+
+	td, _ := client.TestDataSource()
+	td.Update(td.Flag("my-kill-switch").VariationForAllUsers(true))
+
+	_, _ = client.QueryBoolWithEvaluationContext("my-kill-switch", evaluationcontext.NewUser("user-id"), false)
+
+	for _, evaluation := range recorder.Evaluations() {
+		fmt.Println(evaluation.Flag, evaluation.UserKey, evaluation.Value)
+	}
+
+	// Output:
+	// my-kill-switch user-id true
+}
@@ -0,0 +1,186 @@
+package flagstest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+)
+
+// Evaluation is a single flag evaluation captured by a Recorder.
+type Evaluation struct {
+	// Flag is the name of the evaluated flag.
+	Flag flags.FlagName
+
+	// UserKey is the key of the evaluation context, or empty if no evaluation
+	// context was available.
+	UserKey string
+
+	// Anonymous is true if the evaluation context was an anonymous user.
+	Anonymous bool
+
+	// Attributes holds the custom attributes of the evaluation context, for
+	// example "accountID".
+	Attributes map[string]interface{}
+
+	// FallbackValue is the fallback value supplied to the query.
+	FallbackValue interface{}
+
+	// Value is the value returned from the query.
+	Value interface{}
+
+	// Err is the error returned from the query, if any.
+	Err error
+}
+
+// Recorder is a flags.Hook that captures every flag evaluation made through
+// a client. Clients returned by NewTestClient record evaluations
+// automatically; a Recorder can also be supplied to any client using
+// flags.WithHooks.
+type Recorder struct {
+	mu          sync.Mutex
+	evaluations []Evaluation
+}
+
+// NewRecorder returns a Recorder with no recorded evaluations.
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// BeforeEvaluation implements flags.Hook.
+func (r *Recorder) BeforeEvaluation(ctx context.Context, _ flags.Evaluation) context.Context {
+	return ctx
+}
+
+// AfterEvaluation implements flags.Hook, recording the evaluation.
+func (r *Recorder) AfterEvaluation(_ context.Context, evaluation flags.Evaluation, result flags.EvaluationResult) flags.EvaluationResult {
+	recorded := Evaluation{
+		Flag:          evaluation.Flag,
+		Attributes:    map[string]interface{}{},
+		FallbackValue: evaluation.FallbackValue,
+		Value:         result.Value,
+		Err:           result.Err,
+	}
+
+	if evaluation.EvaluationContext != nil {
+		user := evaluation.EvaluationContext.ToLDUser()
+		recorded.UserKey = user.GetKey()
+		recorded.Anonymous = user.GetAnonymous()
+
+		custom := user.GetAllCustomMap()
+		for _, key := range custom.Keys() {
+			recorded.Attributes[key] = custom.Get(key).AsArbitraryValue()
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evaluations = append(r.evaluations, recorded)
+
+	return result
+}
+
+// Evaluations returns all recorded evaluations, in the order they were made.
+func (r *Recorder) Evaluations() []Evaluation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]Evaluation(nil), r.evaluations...)
+}
+
+// EvaluationsOf returns the recorded evaluations of the given flag, in the
+// order they were made.
+func (r *Recorder) EvaluationsOf(flag flags.FlagName) []Evaluation {
+	var matching []Evaluation
+	for _, e := range r.Evaluations() {
+		if e.Flag == flag {
+			matching = append(matching, e)
+		}
+	}
+
+	return matching
+}
+
+// Reset discards all recorded evaluations.
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.evaluations = nil
+}
+
+// AssertEvaluated asserts that the flag was evaluated at least once, and
+// returns whether the assertion succeeded.
+func (r *Recorder) AssertEvaluated(t testing.TB, flag flags.FlagName) bool {
+	t.Helper()
+
+	if len(r.EvaluationsOf(flag)) == 0 {
+		t.Errorf("expected flag %q to be evaluated, but it was not", flag)
+		return false
+	}
+
+	return true
+}
+
+// AssertNotEvaluated asserts that the flag was never evaluated, and returns
+// whether the assertion succeeded.
+func (r *Recorder) AssertNotEvaluated(t testing.TB, flag flags.FlagName) bool {
+	t.Helper()
+
+	if n := len(r.EvaluationsOf(flag)); n > 0 {
+		t.Errorf("expected flag %q not to be evaluated, but it was evaluated %d time(s)", flag, n)
+		return false
+	}
+
+	return true
+}
+
+// AssertEvaluatedForUser asserts that the flag was evaluated at least once
+// for the user with the given key, and returns whether the assertion
+// succeeded.
+func (r *Recorder) AssertEvaluatedForUser(t testing.TB, flag flags.FlagName, userKey string) bool {
+	t.Helper()
+
+	evaluations := r.EvaluationsOf(flag)
+	for _, e := range evaluations {
+		if e.UserKey == userKey {
+			return true
+		}
+	}
+
+	t.Errorf("expected flag %q to be evaluated for user %q, but it was evaluated for %v", flag, userKey, userKeys(evaluations))
+	return false
+}
+
+// AssertEvaluatedWithResult asserts that the most recent evaluation of the
+// flag returned the given value, and returns whether the assertion
+// succeeded.
+func (r *Recorder) AssertEvaluatedWithResult(t testing.TB, flag flags.FlagName, value interface{}) bool {
+	t.Helper()
+
+	evaluations := r.EvaluationsOf(flag)
+	if len(evaluations) == 0 {
+		t.Errorf("expected flag %q to be evaluated, but it was not", flag)
+		return false
+	}
+
+	last := evaluations[len(evaluations)-1]
+	if !reflect.DeepEqual(last.Value, value) {
+		t.Errorf("expected flag %q to be evaluated with result %#v, but the result was %#v", flag, value, last.Value)
+		return false
+	}
+
+	return true
+}
+
+func userKeys(evaluations []Evaluation) []string {
+	keys := make([]string, 0, len(evaluations))
+	for _, e := range evaluations {
+		keys = append(keys, e.UserKey)
+	}
+
+	return keys
+}
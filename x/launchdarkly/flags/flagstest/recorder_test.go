@@ -0,0 +1,129 @@
+package flagstest_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagstest"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTB captures assertion failures so that failing assertions can be
+// tested.
+type fakeTB struct {
+	testing.TB
+	errors []string
+}
+
+func (f *fakeTB) Helper() {}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+	f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
+
+func TestRecorder(t *testing.T) {
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+		CustomerAccountID: "account-id",
+		UserID:            "user-id",
+		RealUserID:        "real-user-id",
+	})
+
+	t.Run("records evaluations made through the test client", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetBool("kill-switch", true)
+
+		_, err := client.QueryBool(ctx, "kill-switch", false)
+		require.NoError(t, err)
+		_, err = client.QueryStringWithEvaluationContext("missing-flag", evaluationcontext.NewAnonymousUser("request-id"), "fallback")
+		require.Error(t, err)
+
+		evaluations := client.Evaluations()
+		require.Len(t, evaluations, 2)
+
+		assert.Equal(t, flags.FlagName("kill-switch"), evaluations[0].Flag)
+		assert.Equal(t, "user-id", evaluations[0].UserKey)
+		assert.False(t, evaluations[0].Anonymous)
+		assert.Equal(t, "account-id", evaluations[0].Attributes["accountID"])
+		assert.Equal(t, "real-user-id", evaluations[0].Attributes["realUserID"])
+		assert.Equal(t, false, evaluations[0].FallbackValue)
+		assert.Equal(t, true, evaluations[0].Value)
+		assert.NoError(t, evaluations[0].Err)
+
+		assert.Equal(t, flags.FlagName("missing-flag"), evaluations[1].Flag)
+		assert.Equal(t, "request-id", evaluations[1].UserKey)
+		assert.True(t, evaluations[1].Anonymous)
+		assert.Equal(t, "fallback", evaluations[1].Value)
+		assert.Error(t, evaluations[1].Err)
+
+		assert.Len(t, client.EvaluationsOf("kill-switch"), 1)
+
+		client.Reset()
+		assert.Empty(t, client.Evaluations())
+	})
+
+	t.Run("records evaluations without a user", func(t *testing.T) {
+		client := flagstest.NewTestClient(t)
+
+		_, err := client.QueryBool(context.Background(), "kill-switch", false)
+		require.Error(t, err)
+
+		evaluations := client.EvaluationsOf("kill-switch")
+		require.Len(t, evaluations, 1)
+		assert.Empty(t, evaluations[0].UserKey)
+		assert.Error(t, evaluations[0].Err)
+	})
+
+	t.Run("passing assertions", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetString("string-flag", "value")
+
+		_, err := client.QueryString(ctx, "string-flag", "fallback")
+		require.NoError(t, err)
+
+		assert.True(t, client.AssertEvaluated(t, "string-flag"))
+		assert.True(t, client.AssertNotEvaluated(t, "other-flag"))
+		assert.True(t, client.AssertEvaluatedForUser(t, "string-flag", "user-id"))
+		assert.True(t, client.AssertEvaluatedWithResult(t, "string-flag", "value"))
+	})
+
+	t.Run("failing assertions", func(t *testing.T) {
+		client := flagstest.NewTestClient(t).
+			SetString("string-flag", "value")
+
+		_, err := client.QueryString(ctx, "string-flag", "fallback")
+		require.NoError(t, err)
+
+		tb := &fakeTB{}
+		assert.False(t, client.AssertEvaluated(tb, "other-flag"))
+		assert.False(t, client.AssertNotEvaluated(tb, "string-flag"))
+		assert.False(t, client.AssertEvaluatedForUser(tb, "string-flag", "other-user-id"))
+		assert.False(t, client.AssertEvaluatedWithResult(tb, "string-flag", "other-value"))
+		assert.False(t, client.AssertEvaluatedWithResult(tb, "other-flag", "value"))
+
+		assert.Equal(t, []string{
+			`expected flag "other-flag" to be evaluated, but it was not`,
+			`expected flag "string-flag" not to be evaluated, but it was evaluated 1 time(s)`,
+			`expected flag "string-flag" to be evaluated for user "other-user-id", but it was evaluated for [user-id]`,
+			`expected flag "string-flag" to be evaluated with result "other-value", but the result was "value"`,
+			`expected flag "other-flag" to be evaluated, but it was not`,
+		}, tb.errors)
+	})
+
+	t.Run("can be added to any client as a hook", func(t *testing.T) {
+		recorder := flagstest.NewRecorder()
+		client, err := flags.NewClient(flags.WithTestMode(nil), flags.WithHooks(recorder))
+		require.NoError(t, err)
+		require.NoError(t, client.Connect())
+
+		_, err = client.QueryIntWithEvaluationContext("int-flag", evaluationcontext.NewUser("user-id"), 1)
+		require.Error(t, err)
+
+		recorder.AssertEvaluatedForUser(t, "int-flag", "user-id")
+		recorder.AssertEvaluatedWithResult(t, "int-flag", 1)
+	})
+}
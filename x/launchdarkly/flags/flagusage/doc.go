@@ -0,0 +1,31 @@
+// Package flagusage finds the feature flags referenced by Go code and checks
+// them against the flags defined in LaunchDarkly, to help find dead flags.
+//
+// A flag is referenced by a package if the package declares a constant of type
+// flags.FlagName, or passes a constant flag name to a function or method of
+// the flags package, such as Client.QueryBool. Tests are loaded with the
+// packages, so flags only referenced by tests are still referenced. Flag names
+// that are computed at runtime can't be detected.
+//
+// Check loads the packages of a module and reports both flags that are
+// referenced in code but not defined in LaunchDarkly, and flags that are
+// defined in LaunchDarkly but never referenced:
+//   reference, err := flagfile.ReadFile("export.json")
+//   if err != nil {
+//     // handle missing or invalid flag data
+//   }
+//
+//   report, err := flagusage.Check(".", []string{"./..."}, reference)
+//
+// Flag data is read with flagfile.ReadFile, so it can be a .ld-flags.json file
+// or flag data exported from LaunchDarkly. The flagusage command (in
+// ../cmd/flagusage) wraps Check for use on the command line and in CI.
+//
+// Analyzer reports references to undefined flags one package at a time, and
+// can be used with drivers such as singlechecker or multichecker:
+//   flagusage.Analyzer.Flags.Set("flags", ".ld-flags.json")
+//   singlechecker.Main(flagusage.Analyzer)
+//
+// Finding flags that are never referenced requires the whole module, so it is
+// only supported by Check.
+package flagusage
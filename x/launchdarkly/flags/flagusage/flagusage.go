@@ -0,0 +1,251 @@
+package flagusage
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+	"sort"
+	"sync"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagfile"
+	"golang.org/x/tools/go/analysis"
+)
+
+const flagsPackagePath = "github.com/cultureamp/ca-go/x/launchdarkly/flags"
+
+// Reference is a use of a flag name in code.
+type Reference struct {
+	// Flag is the name of the referenced flag.
+	Flag string
+
+	// Pos is the position of the reference.
+	Pos token.Position
+}
+
+// Report is the result of Check.
+type Report struct {
+	// Undefined holds references to flags that are not defined in the
+	// reference flag data, sorted by position.
+	Undefined []Reference
+
+	// Unreferenced holds the names of flags that are defined in the reference
+	// flag data but never referenced in code, sorted by name.
+	Unreferenced []string
+}
+
+// Empty returns true if the report contains no findings.
+func (r Report) Empty() bool {
+	return len(r.Undefined) == 0 && len(r.Unreferenced) == 0
+}
+
+// Analyzer reports references to flags that are not defined in the flag data
+// file given by its -flags option. If the option is not set, Analyzer reports
+// nothing.
+var Analyzer = &analysis.Analyzer{
+	Name: "flagusage",
+	Doc:  "report references to feature flags that are not defined in LaunchDarkly",
+	Run:  run,
+}
+
+func init() {
+	Analyzer.Flags.String("flags", "", "path of a .ld-flags.json file or flag data exported from LaunchDarkly")
+}
+
+func run(pass *analysis.Pass) (interface{}, error) {
+	flagsFilename := pass.Analyzer.Flags.Lookup("flags").Value.String()
+	if flagsFilename == "" {
+		return nil, nil
+	}
+
+	reference, err := readFlagData(flagsFilename)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range collect(pass.Fset, pass.Files, pass.TypesInfo) {
+		if _, ok := reference.FlagValues[ref.Flag]; !ok {
+			pass.Reportf(ref.pos, "flag %q is not defined in %s", ref.Flag, flagsFilename)
+		}
+	}
+
+	return nil, nil
+}
+
+// Check loads the packages matching the patterns, relative to dir, and
+// compares the flags they reference with the flags in reference.
+func Check(dir string, patterns []string, reference flagfile.File) (Report, error) {
+	pkgs, err := load(dir, patterns)
+	if err != nil {
+		return Report{}, err
+	}
+
+	var report Report
+	referenced := map[string]bool{}
+
+	for _, pkg := range pkgs {
+		for _, ref := range collect(pkg.Fset, pkg.Syntax, pkg.TypesInfo) {
+			referenced[ref.Flag] = true
+
+			if _, ok := reference.FlagValues[ref.Flag]; !ok {
+				report.Undefined = append(report.Undefined, ref.Reference)
+			}
+		}
+	}
+
+	for _, name := range reference.Names() {
+		if !referenced[name] {
+			report.Unreferenced = append(report.Unreferenced, name)
+		}
+	}
+
+	sort.Slice(report.Undefined, func(i, j int) bool {
+		a, b := report.Undefined[i].Pos, report.Undefined[j].Pos
+		if a.Filename != b.Filename {
+			return a.Filename < b.Filename
+		}
+		return a.Offset < b.Offset
+	})
+
+	return report, nil
+}
+
+// reference is a Reference that also records its token.Pos, for reporting
+// diagnostics.
+type reference struct {
+	Reference
+	pos token.Pos
+}
+
+// collect finds the flag references in the files of a package: declarations
+// of FlagName constants, and constant FlagName arguments to functions in the
+// flags package that don't refer to such a declaration.
+func collect(fset *token.FileSet, files []*ast.File, info *types.Info) []reference {
+	var refs []reference
+
+	add := func(name string, pos token.Pos) {
+		refs = append(refs, reference{Reference: Reference{Flag: name, Pos: fset.Position(pos)}, pos: pos})
+	}
+
+	for _, file := range files {
+		ast.Inspect(file, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.ValueSpec:
+				for _, ident := range n.Names {
+					c, ok := info.Defs[ident].(*types.Const)
+					if ok && isFlagName(c.Type()) && c.Val().Kind() == constant.String {
+						add(constant.StringVal(c.Val()), ident.Pos())
+					}
+				}
+			case *ast.CallExpr:
+				if !isFlagsFunc(info, n.Fun) {
+					return true
+				}
+
+				for _, arg := range n.Args {
+					tv, ok := info.Types[arg]
+					if !ok || !isFlagName(tv.Type) || tv.Value == nil || tv.Value.Kind() != constant.String {
+						continue
+					}
+
+					if refersToFlagNameConst(info, arg) {
+						continue
+					}
+
+					add(constant.StringVal(tv.Value), arg.Pos())
+				}
+			}
+
+			return true
+		})
+	}
+
+	return refs
+}
+
+// isFlagName returns true if t is flags.FlagName.
+func isFlagName(t types.Type) bool {
+	named, ok := t.(*types.Named)
+	if !ok {
+		return false
+	}
+
+	obj := named.Obj()
+	return obj.Name() == "FlagName" && obj.Pkg() != nil && obj.Pkg().Path() == flagsPackagePath
+}
+
+// isFlagsFunc returns true if fun is a function or method declared in the
+// flags package.
+func isFlagsFunc(info *types.Info, fun ast.Expr) bool {
+	switch f := unparen(fun).(type) {
+	case *ast.IndexExpr:
+		fun = f.X
+	case *ast.IndexListExpr:
+		fun = f.X
+	}
+
+	var ident *ast.Ident
+	switch f := unparen(fun).(type) {
+	case *ast.Ident:
+		ident = f
+	case *ast.SelectorExpr:
+		ident = f.Sel
+	default:
+		return false
+	}
+
+	fn, ok := info.Uses[ident].(*types.Func)
+	return ok && fn.Pkg() != nil && fn.Pkg().Path() == flagsPackagePath
+}
+
+// refersToFlagNameConst returns true if expr names a FlagName constant, whose
+// declaration is already counted as a reference.
+func refersToFlagNameConst(info *types.Info, expr ast.Expr) bool {
+	var ident *ast.Ident
+	switch e := unparen(expr).(type) {
+	case *ast.Ident:
+		ident = e
+	case *ast.SelectorExpr:
+		ident = e.Sel
+	default:
+		return false
+	}
+
+	c, ok := info.Uses[ident].(*types.Const)
+	return ok && isFlagName(c.Type())
+}
+
+var (
+	flagDataMu sync.Mutex
+	flagData   = map[string]flagfile.File{}
+)
+
+// readFlagData reads and caches flag data, so it is read once rather than
+// once per analyzed package.
+func readFlagData(filename string) (flagfile.File, error) {
+	flagDataMu.Lock()
+	defer flagDataMu.Unlock()
+
+	if f, ok := flagData[filename]; ok {
+		return f, nil
+	}
+
+	f, err := flagfile.ReadFile(filename)
+	if err != nil {
+		return flagfile.File{}, err
+	}
+
+	flagData[filename] = f
+
+	return f, nil
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
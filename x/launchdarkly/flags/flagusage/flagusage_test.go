@@ -0,0 +1,87 @@
+package flagusage
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/flagfile"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/tools/go/analysis"
+)
+
+func TestAnalyzer(t *testing.T) {
+	pkgs, err := load(".", []string{"./testdata/usage"})
+	require.NoError(t, err)
+	require.Len(t, pkgs, 2, "the package with its tests, and its external test package")
+
+	run := func(t *testing.T) []string {
+		t.Helper()
+
+		var diagnostics []string
+		for _, pkg := range pkgs {
+			fset := pkg.Fset
+			pass := &analysis.Pass{
+				Analyzer:  Analyzer,
+				Fset:      fset,
+				Files:     pkg.Syntax,
+				Pkg:       pkg.Types,
+				TypesInfo: pkg.TypesInfo,
+				Report: func(d analysis.Diagnostic) {
+					pos := fset.Position(d.Pos)
+					diagnostics = append(diagnostics, fmt.Sprintf("%s:%d: %s", filepath.Base(pos.Filename), pos.Line, d.Message))
+				},
+			}
+
+			_, err := Analyzer.Run(pass)
+			require.NoError(t, err)
+		}
+
+		return diagnostics
+	}
+
+	t.Run("reports references to undefined flags", func(t *testing.T) {
+		require.NoError(t, Analyzer.Flags.Set("flags", "testdata/flags.json"))
+		t.Cleanup(func() { _ = Analyzer.Flags.Set("flags", "") })
+
+		assert.Equal(t, []string{
+			`usage.go:11: flag "undefined-flag" is not defined in testdata/flags.json`,
+			`usage.go:20: flag "undefined-literal-flag" is not defined in testdata/flags.json`,
+			`usage_test.go:13: flag "undefined-test-flag" is not defined in testdata/flags.json`,
+		}, run(t))
+	})
+
+	t.Run("reports nothing without flag data", func(t *testing.T) {
+		assert.Empty(t, run(t))
+	})
+}
+
+func TestCheck(t *testing.T) {
+	reference, err := flagfile.ReadFile("testdata/flags.json")
+	require.NoError(t, err)
+
+	report, err := Check(".", []string{"./testdata/usage"}, reference)
+	require.NoError(t, err)
+	assert.False(t, report.Empty())
+
+	var undefined []string
+	for _, ref := range report.Undefined {
+		undefined = append(undefined, fmt.Sprintf("%s %s:%d", ref.Flag, filepath.Base(ref.Pos.Filename), ref.Pos.Line))
+	}
+
+	assert.Equal(t, []string{"undefined-flag usage.go:11", "undefined-literal-flag usage.go:20", "undefined-test-flag usage_test.go:13"}, undefined)
+	assert.Equal(t, []string{"unused-flag"}, report.Unreferenced, "flags referenced only by tests are referenced")
+}
+
+func TestCheckLoadErrors(t *testing.T) {
+	t.Run("reports missing packages", func(t *testing.T) {
+		_, err := Check(".", []string{"./testdata/does-not-exist"}, flagfile.File{})
+		require.Error(t, err)
+	})
+
+	t.Run("reports type errors", func(t *testing.T) {
+		_, err := Check(".", []string{"./testdata/typeerror"}, flagfile.File{})
+		require.ErrorContains(t, err, "typeerror.go:3")
+	})
+}
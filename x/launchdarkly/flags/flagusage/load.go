@@ -0,0 +1,60 @@
+package flagusage
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// load loads the packages matching the patterns, including their tests,
+// parsed and type-checked. An error is returned if any of the packages, or
+// their dependencies, fail to load or type-check.
+func load(dir string, patterns []string) ([]*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:  packages.NeedName | packages.NeedFiles | packages.NeedImports | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo,
+		Dir:   dir,
+		Tests: true,
+	}
+
+	pkgs, err := packages.Load(cfg, patterns...)
+	if err != nil {
+		return nil, fmt.Errorf("load packages: %w", err)
+	}
+
+	var loadErrs []string
+	packages.Visit(pkgs, nil, func(pkg *packages.Package) {
+		for _, err := range pkg.Errors {
+			loadErrs = append(loadErrs, err.Error())
+		}
+	})
+	if len(loadErrs) > 0 {
+		return nil, fmt.Errorf("load packages: %s", strings.Join(loadErrs, "; "))
+	}
+
+	return withoutTestDuplicates(pkgs), nil
+}
+
+// withoutTestDuplicates removes the packages loaded for tests that would
+// otherwise be checked twice: a package with tests is loaded both with and
+// without its test files, and each test binary has a generated main package.
+// The variant of a package with its test files has the ID "path [path.test]".
+func withoutTestDuplicates(pkgs []*packages.Package) []*packages.Package {
+	tested := map[string]bool{}
+	for _, pkg := range pkgs {
+		if pkg.ID == fmt.Sprintf("%s [%s.test]", pkg.PkgPath, pkg.PkgPath) {
+			tested[pkg.PkgPath] = true
+		}
+	}
+
+	filtered := make([]*packages.Package, 0, len(pkgs))
+	for _, pkg := range pkgs {
+		isTestMain := pkg.Name == "main" && strings.HasSuffix(pkg.PkgPath, ".test")
+		if isTestMain || (pkg.ID == pkg.PkgPath && tested[pkg.PkgPath]) {
+			continue
+		}
+		filtered = append(filtered, pkg)
+	}
+
+	return filtered
+}
@@ -0,0 +1,3 @@
+package typeerror
+
+var count int = "not an int"
@@ -0,0 +1,31 @@
+package usage
+
+import (
+	"context"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+)
+
+const (
+	DefinedFlag   flags.FlagName = "defined-flag"
+	UndefinedFlag flags.FlagName = "undefined-flag"
+
+	notAFlag = "not-a-flag"
+)
+
+func use(ctx context.Context, c *flags.Client) {
+	_, _ = c.QueryBool(ctx, DefinedFlag, false)
+	_, _ = c.QueryBool(ctx, UndefinedFlag, false)
+	_, _ = c.QueryString(ctx, "literal-flag", "")
+	_, _ = c.QueryInt(ctx, "undefined-literal-flag", 0)
+	_, _ = c.QueryInt(ctx, (flags.FlagName)("converted-flag"), 0)
+
+	computed := flags.FlagName(notAFlag + "-computed")
+	_, _ = c.QueryBool(ctx, computed, false)
+
+	query(ctx, c, "not-a-flags-function")
+}
+
+func query(ctx context.Context, c *flags.Client, key flags.FlagName) {
+	_, _ = c.QueryBool(ctx, key, false)
+}
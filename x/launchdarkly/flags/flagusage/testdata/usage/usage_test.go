@@ -0,0 +1,14 @@
+package usage_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags"
+)
+
+func TestUse(t *testing.T) {
+	var c *flags.Client
+	_, _ = c.QueryBool(context.Background(), "test-only-flag", false)
+	_, _ = c.QueryBool(context.Background(), "undefined-test-flag", false)
+}
@@ -0,0 +1,131 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+// Hook is implemented by types that need to observe or alter every flag
+// evaluation made through a Client, for example to write audit logs or to
+// override results in certain environments. Hooks are supplied with the
+// WithHooks option and are run in the order they were registered.
+type Hook interface {
+	// BeforeEvaluation is called before a flag is evaluated. The returned
+	// context is passed to subsequent hooks, and to AfterEvaluation.
+	BeforeEvaluation(ctx context.Context, evaluation Evaluation) context.Context
+
+	// AfterEvaluation is called after a flag is evaluated. The returned result
+	// is passed to subsequent hooks and, once all hooks have run, returned to
+	// the caller of the Query method. Hooks that don't alter the result must
+	// return it unchanged. If a hook replaces the value, it must be of the same
+	// type as the fallback value.
+	AfterEvaluation(ctx context.Context, evaluation Evaluation, result EvaluationResult) EvaluationResult
+}
+
+// Evaluation describes the flag being evaluated.
+type Evaluation struct {
+	// Flag is the name of the flag being evaluated.
+	Flag FlagName
+
+	// EvaluationContext is the context the flag is evaluated against. It is nil
+	// if an evaluation context could not be extracted from the request context.
+	EvaluationContext evaluationcontext.Context
+
+	// FallbackValue is the fallback value supplied to the Query method.
+	FallbackValue interface{}
+}
+
+// EvaluationResult describes the outcome of a flag evaluation.
+type EvaluationResult struct {
+	// Value is the value returned from the evaluation. It has the same type
+	// as the fallback value.
+	Value interface{}
+
+	// Detail explains how the value was determined.
+	Detail EvaluationDetail
+
+	// Err is the error returned from the evaluation, if any. The value is
+	// always the fallback value when Err is not nil.
+	Err error
+}
+
+// EvaluationDetail explains how the value of a flag evaluation was determined.
+type EvaluationDetail struct {
+	// VariationIndex is the index of the flag variation that was served. It
+	// is undefined if the fallback value was served.
+	VariationIndex ldvalue.OptionalInt
+
+	// Reason is the LaunchDarkly evaluation reason. It is undefined if the
+	// value was overridden.
+	Reason ldreason.EvaluationReason
+
+	// Source is where the value was sourced from: SourceLaunchDarkly, or the
+	// override that supplied it (see WithOverrides). Override sources are
+	// "env:" followed by the environment variable name, or "file:" followed
+	// by the overrides filename.
+	Source string
+}
+
+// Overridden returns whether the value was sourced from an override rather
+// than from LaunchDarkly.
+func (d EvaluationDetail) Overridden() bool {
+	return d.Source != "" && d.Source != SourceLaunchDarkly
+}
+
+// WithHooks configures the client to run the given hooks before and after
+// each flag evaluation. This option can be supplied more than once; hooks are
+// run in the order they were registered.
+func WithHooks(hooks ...Hook) ConfigOption {
+	return func(c *Client) {
+		c.hooks = append(c.hooks, hooks...)
+	}
+}
+
+// evaluate runs the variation function between the client's hooks, unless the
+// flag is overridden. The value and detail from the final hook result are
+// returned, provided the value has the same type as the fallback value.
+func evaluate[T any](ctx context.Context, c *Client, evaluation Evaluation, fallbackValue T, variation func() (T, ldreason.EvaluationDetail, error)) (T, EvaluationDetail, error) {
+	for _, hook := range c.hooks {
+		ctx = hook.BeforeEvaluation(ctx, evaluation)
+	}
+
+	var (
+		value  T
+		detail ldreason.EvaluationDetail
+		err    error
+		source = SourceLaunchDarkly
+	)
+
+	// Overrides take precedence, and the wrapped client is not consulted.
+	if o, ok := c.overrides.lookup(evaluation.Flag); ok {
+		value, detail, err = overrideResult(o, fallbackValue)
+		source = o.source
+	} else {
+		value, detail, err = variation()
+	}
+
+	result := EvaluationResult{
+		Value: value,
+		Detail: EvaluationDetail{
+			VariationIndex: detail.VariationIndex,
+			Reason:         detail.Reason,
+			Source:         source,
+		},
+		Err: err,
+	}
+
+	for _, hook := range c.hooks {
+		result = hook.AfterEvaluation(ctx, evaluation, result)
+	}
+
+	typedValue, ok := result.Value.(T)
+	if !ok {
+		return fallbackValue, result.Detail, fmt.Errorf("hook returned a value of type %T for flag %s, expected %T", result.Value, evaluation.Flag, fallbackValue)
+	}
+
+	return typedValue, result.Detail, result.Err
+}
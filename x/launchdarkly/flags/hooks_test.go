@@ -0,0 +1,140 @@
+package flags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+type hookContextKey string
+
+// recordingHook records the calls made to it, and optionally replaces the
+// result value.
+type recordingHook struct {
+	name     string
+	calls    *[]string
+	override interface{}
+
+	evaluations []Evaluation
+	results     []EvaluationResult
+	ctxValues   []interface{}
+}
+
+func (h *recordingHook) BeforeEvaluation(ctx context.Context, evaluation Evaluation) context.Context {
+	*h.calls = append(*h.calls, "before "+h.name)
+	return context.WithValue(ctx, hookContextKey(h.name), h.name)
+}
+
+func (h *recordingHook) AfterEvaluation(ctx context.Context, evaluation Evaluation, result EvaluationResult) EvaluationResult {
+	*h.calls = append(*h.calls, "after "+h.name)
+	h.evaluations = append(h.evaluations, evaluation)
+	h.results = append(h.results, result)
+	h.ctxValues = append(h.ctxValues, ctx.Value(hookContextKey(h.name)))
+
+	if h.override != nil {
+		result.Value = h.override
+	}
+
+	return result
+}
+
+func newHooksTestClient(t *testing.T, hooks ...Hook) *Client {
+	t.Helper()
+
+	c, err := NewClient(WithTestMode(nil), WithHooks(hooks...))
+	require.NoError(t, err)
+	require.NoError(t, c.Connect())
+
+	td, err := c.TestDataSource()
+	require.NoError(t, err)
+	td.Update(td.Flag("test-flag").VariationForAllUsers(true))
+
+	return c
+}
+
+func TestClientHooks(t *testing.T) {
+	t.Run("runs hooks in registration order", func(t *testing.T) {
+		var calls []string
+		first := &recordingHook{name: "first", calls: &calls}
+		second := &recordingHook{name: "second", calls: &calls}
+		c := newHooksTestClient(t, first, second)
+
+		_, err := c.QueryBoolWithEvaluationContext("test-flag", evaluationcontext.NewUser("user-id"), false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"before first", "before second", "after first", "after second"}, calls)
+		assert.Equal(t, []interface{}{"first"}, first.ctxValues)
+	})
+
+	t.Run("supplies the evaluation and result to hooks", func(t *testing.T) {
+		var calls []string
+		hook := &recordingHook{name: "hook", calls: &calls}
+		c := newHooksTestClient(t, hook)
+
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+			UserID: "user-id",
+		})
+		_, err := c.QueryBool(ctx, "test-flag", false)
+		require.NoError(t, err)
+
+		require.Len(t, hook.evaluations, 1)
+		assert.Equal(t, FlagName("test-flag"), hook.evaluations[0].Flag)
+		assert.Equal(t, false, hook.evaluations[0].FallbackValue)
+		assert.Equal(t, "user-id", hook.evaluations[0].EvaluationContext.ToLDUser().GetKey())
+
+		require.Len(t, hook.results, 1)
+		assert.Equal(t, true, hook.results[0].Value)
+		assert.Equal(t, 0, hook.results[0].Detail.VariationIndex.IntValue())
+		assert.Equal(t, ldreason.EvalReasonFallthrough, hook.results[0].Detail.Reason.GetKind())
+		assert.NoError(t, hook.results[0].Err)
+	})
+
+	t.Run("supplies errors to hooks", func(t *testing.T) {
+		var calls []string
+		hook := &recordingHook{name: "hook", calls: &calls}
+		c := newHooksTestClient(t, hook)
+
+		_, err := c.QueryString(context.Background(), "test-flag", "fallback")
+		require.Error(t, err)
+
+		require.Len(t, hook.evaluations, 1)
+		assert.Nil(t, hook.evaluations[0].EvaluationContext)
+		assert.Equal(t, "fallback", hook.results[0].Value)
+		assert.Equal(t, err, hook.results[0].Err)
+		assert.Equal(t, ldreason.EvalErrorUserNotSpecified, hook.results[0].Detail.Reason.GetErrorKind())
+	})
+
+	t.Run("allows hooks to override the result", func(t *testing.T) {
+		var calls []string
+		first := &recordingHook{name: "first", calls: &calls, override: "overridden"}
+		second := &recordingHook{name: "second", calls: &calls}
+		c := newHooksTestClient(t, first, second)
+
+		td, err := c.TestDataSource()
+		require.NoError(t, err)
+		td.Update(td.Flag("string-flag").ValueForAllUsers(ldvalue.String("original")))
+
+		res, err := c.QueryStringWithEvaluationContext("string-flag", evaluationcontext.NewUser("user-id"), "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "overridden", res)
+
+		// later hooks see the overridden result
+		assert.Equal(t, "overridden", second.results[0].Value)
+	})
+
+	t.Run("returns the fallback if a hook overrides the result with the wrong type", func(t *testing.T) {
+		var calls []string
+		hook := &recordingHook{name: "hook", calls: &calls, override: "not-a-bool"}
+		c := newHooksTestClient(t, hook)
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evaluationcontext.NewUser("user-id"), false)
+		require.EqualError(t, err, "hook returned a value of type string for flag test-flag, expected bool")
+		assert.False(t, res)
+	})
+}
@@ -0,0 +1,166 @@
+package flags
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+const (
+	// SourceLaunchDarkly is the EvaluationDetail source for values evaluated
+	// by LaunchDarkly (or the test data source in test mode).
+	SourceLaunchDarkly = "launchdarkly"
+
+	// DefaultOverrideEnvironmentPrefix is the prefix of environment variables
+	// that override flag values, unless configured otherwise.
+	DefaultOverrideEnvironmentPrefix = "FLAG_OVERRIDE_"
+)
+
+// OverrideConfig declares where flag overrides are sourced from. Overridden
+// flags are never evaluated by LaunchDarkly, so an override continues to
+// work even if LaunchDarkly is unavailable. This makes overrides suitable for
+// forcing a flag value locally during development, or as an emergency kill
+// switch.
+type OverrideConfig struct {
+	// FromEnvironment enables overrides from environment variables. The name
+	// of the variable is the EnvironmentPrefix followed by the flag name in
+	// upper case, with any characters other than letters and digits replaced
+	// by underscores. For example, "my-flag" is overridden by
+	// FLAG_OVERRIDE_MY_FLAG. The value is parsed as JSON where possible;
+	// values for string flags may also be supplied unquoted.
+	FromEnvironment bool
+
+	// EnvironmentPrefix overrides DefaultOverrideEnvironmentPrefix.
+	EnvironmentPrefix string
+
+	// Filename is the path of an optional JSON file containing an object that
+	// maps flag names to override values, for example:
+	//   {"my-flag": true, "my-string-flag": "value"}
+	// The file is read when the client is created. Environment variable
+	// overrides take precedence over values in the file.
+	Filename string
+}
+
+// WithOverrides configures the client to source flag values from a local
+// override layer that takes precedence over LaunchDarkly. The source of an
+// overridden value is reported in the EvaluationDetail passed to hooks.
+func WithOverrides(cfg *OverrideConfig) ConfigOption {
+	return func(c *Client) {
+		c.overrideConfig = cfg
+	}
+}
+
+// override is a single overridden flag value.
+type override struct {
+	raw     []byte
+	source  string
+	fromEnv bool
+}
+
+// overrides looks up overridden flag values. A nil *overrides has no
+// overrides.
+type overrides struct {
+	envPrefix string
+	fromFile  map[FlagName]override
+}
+
+func newOverrides(cfg *OverrideConfig) (*overrides, error) {
+	o := &overrides{}
+
+	if cfg.FromEnvironment {
+		o.envPrefix = DefaultOverrideEnvironmentPrefix
+		if cfg.EnvironmentPrefix != "" {
+			o.envPrefix = cfg.EnvironmentPrefix
+		}
+	}
+
+	if cfg.Filename != "" {
+		contents, err := os.ReadFile(cfg.Filename)
+		if err != nil {
+			return nil, fmt.Errorf("read overrides file: %w", err)
+		}
+
+		values := map[string]json.RawMessage{}
+		if err := json.Unmarshal(contents, &values); err != nil {
+			return nil, fmt.Errorf("parse overrides file %s: %w", cfg.Filename, err)
+		}
+
+		o.fromFile = make(map[FlagName]override, len(values))
+		for key, value := range values {
+			o.fromFile[FlagName(key)] = override{
+				raw:    value,
+				source: "file:" + cfg.Filename,
+			}
+		}
+	}
+
+	return o, nil
+}
+
+// lookup returns the override for the given flag, if there is one.
+func (o *overrides) lookup(key FlagName) (override, bool) {
+	if o == nil {
+		return override{}, false
+	}
+
+	if o.envPrefix != "" {
+		name := overrideEnvironmentVariable(o.envPrefix, key)
+		if value, ok := os.LookupEnv(name); ok {
+			return override{
+				raw:     []byte(value),
+				source:  "env:" + name,
+				fromEnv: true,
+			}, true
+		}
+	}
+
+	value, ok := o.fromFile[key]
+	return value, ok
+}
+
+// overrideEnvironmentVariable returns the name of the environment variable
+// that overrides the given flag.
+func overrideEnvironmentVariable(prefix string, key FlagName) string {
+	name := strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return '_'
+		}
+
+		return unicode.ToUpper(r)
+	}, string(key))
+
+	return prefix + name
+}
+
+// overrideResult converts the override to the type of the fallback value. A
+// JSON null is rejected, as it would otherwise unmarshal to the zero value.
+func overrideResult[T any](o override, fallbackValue T) (T, ldreason.EvaluationDetail, error) {
+	if bytes.Equal(bytes.TrimSpace(o.raw), []byte("null")) {
+		detail := ldreason.NewEvaluationDetailForError(ldreason.EvalErrorWrongType, ldvalue.Null())
+		return fallbackValue, detail, fmt.Errorf("parse override from %s: null is not a valid flag value", o.source)
+	}
+
+	var value T
+
+	err := json.Unmarshal(o.raw, &value)
+	if err != nil && o.fromEnv {
+		// Allow string values in environment variables to be unquoted.
+		if s, ok := interface{}(&value).(*string); ok {
+			*s = string(o.raw)
+			err = nil
+		}
+	}
+
+	if err != nil {
+		detail := ldreason.NewEvaluationDetailForError(ldreason.EvalErrorWrongType, ldvalue.Null())
+		return fallbackValue, detail, fmt.Errorf("parse override from %s: %w", o.source, err)
+	}
+
+	return value, ldreason.EvaluationDetail{}, nil
+}
@@ -0,0 +1,186 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const validOverridesJSON = `
+{
+	"test-flag": false,
+	"my-string-flag-key": "from-file",
+	"my-integer-flag-key": 7
+}
+`
+
+func TestClientOverrides(t *testing.T) {
+	evalContext := evaluationcontext.NewUser("user-id")
+
+	t.Run("overrides flags from environment variables", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_TEST_FLAG", "false")
+		t.Setenv("FLAG_OVERRIDE_MY_STRING_FLAG_KEY", "unquoted value")
+		t.Setenv("FLAG_OVERRIDE_MY_INTEGER_FLAG_KEY", "42")
+
+		c := newOverridesTestClient(t, &OverrideConfig{FromEnvironment: true})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.NoError(t, err)
+		assert.False(t, res)
+
+		res2, err := c.QueryStringWithEvaluationContext("my-string-flag-key", evalContext, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "unquoted value", res2)
+
+		res3, err := c.QueryIntWithEvaluationContext("my-integer-flag-key", evalContext, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 42, res3)
+	})
+
+	t.Run("supports a custom environment variable prefix", func(t *testing.T) {
+		t.Setenv("KILL_SWITCH_TEST_FLAG", "false")
+
+		c := newOverridesTestClient(t, &OverrideConfig{FromEnvironment: true, EnvironmentPrefix: "KILL_SWITCH_"})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.NoError(t, err)
+		assert.False(t, res)
+	})
+
+	t.Run("ignores environment variables unless enabled", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_TEST_FLAG", "false")
+
+		c := newOverridesTestClient(t, &OverrideConfig{})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, false)
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("overrides flags from a file", func(t *testing.T) {
+		filename := writeOverridesFile(t, validOverridesJSON)
+		c := newOverridesTestClient(t, &OverrideConfig{Filename: filename})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.NoError(t, err)
+		assert.False(t, res)
+
+		res2, err := c.QueryStringWithEvaluationContext("my-string-flag-key", evalContext, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "from-file", res2)
+
+		res3, err := c.QueryIntWithEvaluationContext("my-integer-flag-key", evalContext, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 7, res3)
+	})
+
+	t.Run("prefers environment variables to the file", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_MY_STRING_FLAG_KEY", `"from-env"`)
+		filename := writeOverridesFile(t, validOverridesJSON)
+		c := newOverridesTestClient(t, &OverrideConfig{FromEnvironment: true, Filename: filename})
+
+		res, err := c.QueryStringWithEvaluationContext("my-string-flag-key", evalContext, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "from-env", res)
+	})
+
+	t.Run("applies overrides without a connection to LaunchDarkly", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_TEST_FLAG", "true")
+
+		c, err := NewClient(WithTestMode(nil), WithOverrides(&OverrideConfig{FromEnvironment: true}))
+		require.NoError(t, err)
+
+		// No user in the context, and the client was never connected.
+		res, err := c.QueryBool(context.Background(), "test-flag", false)
+		require.NoError(t, err)
+		assert.True(t, res)
+	})
+
+	t.Run("reports the override source in the evaluation detail", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_TEST_FLAG", "false")
+
+		var calls []string
+		hook := &recordingHook{name: "hook", calls: &calls}
+		c := newOverridesTestClient(t, &OverrideConfig{FromEnvironment: true}, WithHooks(hook))
+
+		_, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.NoError(t, err)
+		_, err = c.QueryStringWithEvaluationContext("my-string-flag-key", evalContext, "fallback")
+		require.Error(t, err)
+
+		require.Len(t, hook.results, 2)
+		assert.Equal(t, "env:FLAG_OVERRIDE_TEST_FLAG", hook.results[0].Detail.Source)
+		assert.True(t, hook.results[0].Detail.Overridden())
+		assert.Equal(t, SourceLaunchDarkly, hook.results[1].Detail.Source)
+		assert.False(t, hook.results[1].Detail.Overridden())
+	})
+
+	t.Run("returns the fallback if the override has the wrong type", func(t *testing.T) {
+		t.Setenv("FLAG_OVERRIDE_TEST_FLAG", "not-a-bool")
+
+		c := newOverridesTestClient(t, &OverrideConfig{FromEnvironment: true})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "parse override from env:FLAG_OVERRIDE_TEST_FLAG")
+		assert.True(t, res)
+	})
+
+	t.Run("returns the fallback if the override is null", func(t *testing.T) {
+		filename := writeOverridesFile(t, `{"test-flag": null, "my-string-flag-key": null}`)
+		c := newOverridesTestClient(t, &OverrideConfig{Filename: filename})
+
+		res, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, true)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "null is not a valid flag value")
+		assert.True(t, res)
+
+		res2, err := c.QueryStringWithEvaluationContext("my-string-flag-key", evalContext, "fallback")
+		require.Error(t, err)
+		assert.Equal(t, "fallback", res2)
+	})
+
+	t.Run("errors if the overrides file cannot be read", func(t *testing.T) {
+		_, err := NewClient(WithTestMode(nil), WithOverrides(&OverrideConfig{Filename: "does-not-exist.json"}))
+		require.Error(t, err)
+
+		_, err = NewClient(WithTestMode(nil), WithOverrides(&OverrideConfig{Filename: writeOverridesFile(t, "[]")}))
+		require.Error(t, err)
+	})
+}
+
+func TestOverrideEnvironmentVariable(t *testing.T) {
+	assert.Equal(t, "FLAG_OVERRIDE_MY_FLAG", overrideEnvironmentVariable(DefaultOverrideEnvironmentPrefix, "my-flag"))
+	assert.Equal(t, "FLAG_OVERRIDE_MY_FLAG_V2", overrideEnvironmentVariable(DefaultOverrideEnvironmentPrefix, "my.flag.v2"))
+	assert.Equal(t, "FLAG_OVERRIDE_CAF_", overrideEnvironmentVariable(DefaultOverrideEnvironmentPrefix, "café"))
+}
+
+// newOverridesTestClient returns a connected client in test mode with the
+// given overrides, serving true for "test-flag".
+func newOverridesTestClient(t *testing.T, cfg *OverrideConfig, opts ...ConfigOption) *Client {
+	t.Helper()
+
+	c, err := NewClient(append([]ConfigOption{WithTestMode(nil), WithOverrides(cfg)}, opts...)...)
+	require.NoError(t, err)
+	require.NoError(t, c.Connect())
+
+	td, err := c.TestDataSource()
+	require.NoError(t, err)
+	td.Update(td.Flag("test-flag").VariationForAllUsers(true))
+
+	return c
+}
+
+func writeOverridesFile(t *testing.T, contents string) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "overrides.json")
+	require.NoError(t, os.WriteFile(filename, []byte(contents), 0o600))
+
+	return filename
+}
@@ -0,0 +1,171 @@
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/lduser"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+// Primitive is the set of flag value types supported by Query. Flags with
+// other types of value, such as objects and arrays, can be queried with
+// QueryJSON.
+type Primitive interface {
+	bool | int | float64 | string
+}
+
+// QueryOptions configures a call to Query or QueryJSON. A nil *QueryOptions
+// is equivalent to an empty QueryOptions.
+type QueryOptions struct {
+	// EvaluationContext is the context the flag is evaluated against. If nil,
+	// user attributes are extracted from the request context.
+	EvaluationContext evaluationcontext.Context
+
+	// Client is the client used to evaluate the flag. If nil, the managed
+	// singleton returned by GetDefaultClient is used.
+	Client *Client
+
+	// Detail, if not nil, is populated with an explanation of how the value
+	// was determined, including the LaunchDarkly evaluation reason. Use this
+	// when the reason is required, for example to record it alongside the
+	// result of an experiment. The reason is then also included in the
+	// analytics events sent to LaunchDarkly, as it is when the client has
+	// hooks or telemetry configured.
+	Detail *EvaluationDetail
+}
+
+// Query retrieves the value of a flag whose value is a bool, int, float64 or
+// string. The type of the flag is taken from the fallback value, which is
+// always reflected in the returned value regardless of whether an error occurs:
+//   enabled, err := flags.Query(ctx, "my-flag", false, nil)
+func Query[T Primitive](ctx context.Context, key FlagName, fallbackValue T, opts *QueryOptions) (T, error) {
+	c, err := opts.client()
+	if err != nil {
+		return fallbackValue, err
+	}
+
+	return queryWithOptions(ctx, c, key, fallbackValue, opts, primitiveVariation[T](c, opts.wantsDetail(c)))
+}
+
+// QueryJSON retrieves the value of a flag whose value is any JSON value, such
+// as an object or an array, and unmarshals it into a value of type T using
+// encoding/json. The fallback value is returned if the flag value can't be
+// unmarshalled into T, and is always reflected in the returned value
+// regardless of whether an error occurs:
+//   type limits struct {
+//     MaxUsers int `json:"maxUsers"`
+//   }
+//
+//   l, err := flags.QueryJSON(ctx, "account-limits", limits{MaxUsers: 10}, nil)
+func QueryJSON[T any](ctx context.Context, key FlagName, fallbackValue T, opts *QueryOptions) (T, error) {
+	c, err := opts.client()
+	if err != nil {
+		return fallbackValue, err
+	}
+
+	return queryWithOptions(ctx, c, key, fallbackValue, opts, jsonVariation[T](c, opts.wantsDetail(c)))
+}
+
+// client returns the client the options select.
+func (o *QueryOptions) client() (*Client, error) {
+	if o != nil && o.Client != nil {
+		return o.Client, nil
+	}
+
+	return GetDefaultClient()
+}
+
+// wantsDetail returns whether the evaluation detail is used, by the caller or
+// by the client's hooks. Only then are the wrapped client's *VariationDetail
+// methods called, as they add the evaluation reason to analytics events.
+func (o *QueryOptions) wantsDetail(c *Client) bool {
+	return (o != nil && o.Detail != nil) || len(c.hooks) > 0
+}
+
+// queryWithOptions evaluates a flag for the evaluation context in opts, or the
+// user extracted from the request context, and fills in opts.Detail.
+func queryWithOptions[T any](ctx context.Context, c *Client, key FlagName, fallbackValue T, opts *QueryOptions, variation variationFunc[T]) (T, error) {
+	var (
+		value  T
+		detail EvaluationDetail
+		err    error
+	)
+
+	if opts != nil && opts.EvaluationContext != nil {
+		value, detail, err = query(ctx, c, key, opts.EvaluationContext, fallbackValue, variation)
+	} else {
+		value, detail, err = queryFromContext(ctx, c, key, fallbackValue, variation)
+	}
+
+	if opts != nil && opts.Detail != nil {
+		*opts.Detail = detail
+	}
+
+	return value, err
+}
+
+// primitiveVariation returns the typed variation method of the wrapped client
+// for T: the *VariationDetail method if withDetail is true, or the plain
+// *Variation method otherwise.
+func primitiveVariation[T Primitive](c *Client, withDetail bool) variationFunc[T] {
+	var variation interface{}
+
+	var zero T
+	switch interface{}(zero).(type) {
+	case bool:
+		variation = selectVariation(withDetail, c.wrappedClient.BoolVariationDetail, c.wrappedClient.BoolVariation)
+	case int:
+		variation = selectVariation(withDetail, c.wrappedClient.IntVariationDetail, c.wrappedClient.IntVariation)
+	case float64:
+		variation = selectVariation(withDetail, c.wrappedClient.Float64VariationDetail, c.wrappedClient.Float64Variation)
+	case string:
+		variation = selectVariation(withDetail, c.wrappedClient.StringVariationDetail, c.wrappedClient.StringVariation)
+	}
+
+	return variation.(variationFunc[T])
+}
+
+// selectVariation returns the detail variation if withDetail is true, or the
+// plain variation adapted to return an empty detail otherwise.
+func selectVariation[T any](withDetail bool, detail variationFunc[T], plain func(key string, user lduser.User, fallbackValue T) (T, error)) variationFunc[T] {
+	if withDetail {
+		return detail
+	}
+
+	return func(key string, user lduser.User, fallbackValue T) (T, ldreason.EvaluationDetail, error) {
+		value, err := plain(key, user, fallbackValue)
+		return value, ldreason.EvaluationDetail{}, err
+	}
+}
+
+// jsonVariation returns a variation function that evaluates a flag with the
+// wrapped client's JSONVariationDetail or JSONVariation method, as selected by
+// withDetail, converting between T and JSON.
+func jsonVariation[T any](c *Client, withDetail bool) variationFunc[T] {
+	variation := selectVariation(withDetail, c.wrappedClient.JSONVariationDetail, c.wrappedClient.JSONVariation)
+
+	return func(key string, user lduser.User, fallbackValue T) (T, ldreason.EvaluationDetail, error) {
+		fallbackJSON, err := json.Marshal(fallbackValue)
+		if err != nil {
+			detail := ldreason.NewEvaluationDetailForError(ldreason.EvalErrorException, ldvalue.Null())
+			return fallbackValue, detail, fmt.Errorf("marshal fallback value: %w", err)
+		}
+
+		value, detail, err := variation(key, user, ldvalue.Parse(fallbackJSON))
+		if err != nil {
+			return fallbackValue, detail, err
+		}
+
+		var result T
+		if err := json.Unmarshal([]byte(value.JSONString()), &result); err != nil {
+			detail = ldreason.NewEvaluationDetailForError(ldreason.EvalErrorWrongType, ldvalue.Null())
+			return fallbackValue, detail, fmt.Errorf("unmarshal value of flag %s: %w", key, err)
+		}
+
+		return result, detail, nil
+	}
+}
@@ -0,0 +1,244 @@
+package flags
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+	ldevents "gopkg.in/launchdarkly/go-sdk-events.v1"
+	"gopkg.in/launchdarkly/go-server-sdk.v5/interfaces"
+)
+
+type testLimits struct {
+	MaxUsers int      `json:"maxUsers"`
+	Regions  []string `json:"regions"`
+}
+
+func TestQuery(t *testing.T) {
+	c, err := NewClient(WithTestMode(nil))
+	require.NoError(t, err)
+	require.NoError(t, c.Connect())
+
+	td, err := c.TestDataSource()
+	require.NoError(t, err)
+	td.Update(td.Flag("bool-flag").VariationForAllUsers(true))
+	td.Update(td.Flag("int-flag").ValueForAllUsers(ldvalue.Int(3)))
+	td.Update(td.Flag("float-flag").ValueForAllUsers(ldvalue.Float64(0.25)))
+	td.Update(td.Flag("string-flag").ValueForAllUsers(ldvalue.String("value")))
+	td.Update(td.Flag("json-flag").ValueForAllUsers(ldvalue.Parse([]byte(`{"maxUsers": 50, "regions": ["au", "us"]}`))))
+
+	evalContext := evaluationcontext.NewUser("user-id")
+	opts := &QueryOptions{Client: c, EvaluationContext: evalContext}
+
+	t.Run("queries primitive flags", func(t *testing.T) {
+		b, err := Query(context.Background(), "bool-flag", false, opts)
+		require.NoError(t, err)
+		assert.True(t, b)
+
+		i, err := Query(context.Background(), "int-flag", 0, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 3, i)
+
+		f, err := Query(context.Background(), "float-flag", 1.0, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 0.25, f)
+
+		s, err := Query(context.Background(), "string-flag", "fallback", opts)
+		require.NoError(t, err)
+		assert.Equal(t, "value", s)
+	})
+
+	t.Run("queries JSON flags", func(t *testing.T) {
+		l, err := QueryJSON(context.Background(), "json-flag", testLimits{MaxUsers: 10}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, testLimits{MaxUsers: 50, Regions: []string{"au", "us"}}, l)
+
+		m, err := QueryJSON(context.Background(), "json-flag", map[string]interface{}{}, opts)
+		require.NoError(t, err)
+		assert.Equal(t, 50.0, m["maxUsers"])
+	})
+
+	t.Run("returns the fallback if a JSON flag can't be unmarshalled", func(t *testing.T) {
+		var detail EvaluationDetail
+		res, err := QueryJSON(context.Background(), "string-flag", testLimits{MaxUsers: 10}, &QueryOptions{
+			Client:            c,
+			EvaluationContext: evalContext,
+			Detail:            &detail,
+		})
+		require.Error(t, err)
+		assert.Equal(t, testLimits{MaxUsers: 10}, res)
+		assert.Equal(t, ldreason.EvalErrorWrongType, detail.Reason.GetErrorKind())
+	})
+
+	t.Run("extracts the user from the context", func(t *testing.T) {
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+			CustomerAccountID: "account-id",
+			UserID:            "user-id",
+		})
+
+		res, err := Query(ctx, "bool-flag", false, &QueryOptions{Client: c})
+		require.NoError(t, err)
+		assert.True(t, res)
+
+		res, err = Query(context.Background(), "bool-flag", false, &QueryOptions{Client: c})
+		require.Error(t, err)
+		assert.False(t, res)
+	})
+
+	t.Run("populates the evaluation detail", func(t *testing.T) {
+		var detail EvaluationDetail
+		_, err := Query(context.Background(), "bool-flag", false, &QueryOptions{
+			Client:            c,
+			EvaluationContext: evalContext,
+			Detail:            &detail,
+		})
+		require.NoError(t, err)
+
+		assert.Equal(t, ldreason.EvalReasonFallthrough, detail.Reason.GetKind())
+		assert.Equal(t, ldvalue.NewOptionalInt(0), detail.VariationIndex)
+		assert.Equal(t, SourceLaunchDarkly, detail.Source)
+	})
+
+	t.Run("uses the default client if no client is supplied", func(t *testing.T) {
+		setDefaultClient(t, c)
+
+		res, err := Query(context.Background(), "string-flag", "fallback", &QueryOptions{EvaluationContext: evalContext})
+		require.NoError(t, err)
+		assert.Equal(t, "value", res)
+	})
+
+	t.Run("returns the fallback if the default client is not configured", func(t *testing.T) {
+		setDefaultClient(t, nil)
+
+		res, err := Query(context.Background(), "string-flag", "fallback", nil)
+		require.ErrorIs(t, err, errClientNotConfigured)
+		assert.Equal(t, "fallback", res)
+	})
+}
+
+func TestQueryEvaluationReasons(t *testing.T) {
+	newClient := func(t *testing.T, opts ...ConfigOption) (*Client, *recordingEventProcessor) {
+		t.Helper()
+
+		c, err := NewClient(append([]ConfigOption{WithTestMode(nil)}, opts...)...)
+		require.NoError(t, err)
+
+		events := &recordingEventProcessor{}
+		c.wrappedConfig.Events = events
+		require.NoError(t, c.Connect())
+
+		td, err := c.TestDataSource()
+		require.NoError(t, err)
+		td.Update(td.Flag("bool-flag").VariationForAllUsers(true))
+		td.Update(td.Flag("json-flag").ValueForAllUsers(ldvalue.Parse([]byte(`{"maxUsers": 50}`))))
+
+		return c, events
+	}
+
+	evalContext := evaluationcontext.NewUser("user-id")
+
+	t.Run("omits reasons unless the detail is requested", func(t *testing.T) {
+		c, events := newClient(t)
+
+		_, err := Query(context.Background(), "bool-flag", false, &QueryOptions{Client: c, EvaluationContext: evalContext})
+		require.NoError(t, err)
+		_, err = QueryJSON(context.Background(), "json-flag", testLimits{}, &QueryOptions{Client: c, EvaluationContext: evalContext})
+		require.NoError(t, err)
+		_, err = c.QueryBoolWithEvaluationContext("bool-flag", evalContext, false)
+		require.NoError(t, err)
+
+		assert.Equal(t, []bool{false, false, false}, events.withReasons())
+	})
+
+	t.Run("includes reasons when the detail is requested", func(t *testing.T) {
+		c, events := newClient(t)
+
+		var detail EvaluationDetail
+		_, err := Query(context.Background(), "bool-flag", false, &QueryOptions{Client: c, EvaluationContext: evalContext, Detail: &detail})
+		require.NoError(t, err)
+		_, err = QueryJSON(context.Background(), "json-flag", testLimits{}, &QueryOptions{Client: c, EvaluationContext: evalContext, Detail: &detail})
+		require.NoError(t, err)
+
+		assert.Equal(t, []bool{true, true}, events.withReasons())
+	})
+
+	t.Run("includes reasons when the client has hooks", func(t *testing.T) {
+		c, events := newClient(t, WithHooks(&recordingHook{calls: &[]string{}}))
+
+		_, err := Query(context.Background(), "bool-flag", false, &QueryOptions{Client: c, EvaluationContext: evalContext})
+		require.NoError(t, err)
+
+		assert.Equal(t, []bool{true}, events.withReasons())
+	})
+}
+
+// recordingEventProcessor records the analytics events created by the wrapped
+// client.
+type recordingEventProcessor struct {
+	mu     sync.Mutex
+	events []ldevents.FeatureRequestEvent
+}
+
+func (p *recordingEventProcessor) CreateEventProcessor(interfaces.ClientContext) (ldevents.EventProcessor, error) {
+	return p, nil
+}
+
+func (p *recordingEventProcessor) RecordFeatureRequestEvent(e ldevents.FeatureRequestEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.events = append(p.events, e)
+}
+
+func (p *recordingEventProcessor) RecordIdentifyEvent(ldevents.IdentifyEvent) {}
+func (p *recordingEventProcessor) RecordCustomEvent(ldevents.CustomEvent)     {}
+func (p *recordingEventProcessor) RecordAliasEvent(ldevents.AliasEvent)       {}
+func (p *recordingEventProcessor) Flush()                                     {}
+func (p *recordingEventProcessor) Close() error                               { return nil }
+
+// withReasons returns whether each recorded event includes the evaluation
+// reason.
+func (p *recordingEventProcessor) withReasons() []bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var reasons []bool
+	for _, e := range p.events {
+		reasons = append(reasons, e.Reason.GetKind() != "")
+	}
+
+	return reasons
+}
+
+// setDefaultClient replaces the managed singleton for the duration of the
+// test. A nil client removes it.
+func setDefaultClient(t *testing.T, c *Client) {
+	t.Helper()
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	previous, ok := clients[defaultClientName]
+	if c == nil {
+		delete(clients, defaultClientName)
+	} else {
+		clients[defaultClientName] = c
+	}
+
+	t.Cleanup(func() {
+		clientsMu.Lock()
+		defer clientsMu.Unlock()
+
+		if ok {
+			clients[defaultClientName] = previous
+		} else {
+			delete(clients, defaultClientName)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package flags
+
+import (
+	"sort"
+	"sync"
+)
+
+// Definition declares a flag that is used by a service.
+type Definition struct {
+	// Name is the name of the flag.
+	Name FlagName
+
+	// Default is the value the flag should take during local development, for
+	// example when writing a .ld-flags.json file. It should be a bool, string,
+	// int, float64 or a value that can be marshalled to JSON.
+	Default interface{}
+
+	// Description is an optional, human-readable description of the flag.
+	Description string
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[FlagName]Definition{}
+)
+
+// Register adds the given flag definitions to the process-wide flag registry.
+// Registering a flag that is already registered replaces its definition.
+// Flags are typically registered in an init function alongside their FlagName
+// constants, so that tooling (see the flagfile package) can discover them.
+func Register(defs ...Definition) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, def := range defs {
+		registry[def.Name] = def
+	}
+}
+
+// Definitions returns all registered flag definitions, sorted by name.
+func Definitions() []Definition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Name < defs[j].Name
+	})
+
+	return defs
+}
@@ -0,0 +1,27 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	Register(
+		Definition{Name: "registry-b", Default: "value"},
+		Definition{Name: "registry-a", Default: true, Description: "first"},
+	)
+	Register(Definition{Name: "registry-a", Default: false, Description: "replaced"})
+
+	var defs []Definition
+	for _, def := range Definitions() {
+		if def.Name == "registry-a" || def.Name == "registry-b" {
+			defs = append(defs, def)
+		}
+	}
+
+	assert.Equal(t, []Definition{
+		{Name: "registry-a", Default: false, Description: "replaced"},
+		{Name: "registry-b", Default: "value"},
+	}, defs)
+}
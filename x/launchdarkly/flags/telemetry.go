@@ -0,0 +1,163 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/global"
+	"go.opentelemetry.io/otel/metric/instrument"
+	"go.opentelemetry.io/otel/trace"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldreason"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+const (
+	instrumentationName = "github.com/cultureamp/ca-go/x/launchdarkly/flags"
+	providerName        = "LaunchDarkly"
+
+	evaluationsCounterName = "feature_flag.evaluations"
+	errorsCounterName      = "feature_flag.evaluation.errors"
+	fallbacksCounterName   = "feature_flag.evaluation.fallbacks"
+
+	// spanEventName and the attribute keys below follow the OpenTelemetry
+	// semantic conventions for feature flags where they exist.
+	spanEventName         = "feature_flag"
+	attributeFlagKey      = attribute.Key("feature_flag.key")
+	attributeProviderName = attribute.Key("feature_flag.provider_name")
+	attributeVariant      = attribute.Key("feature_flag.variant")
+	attributeValue        = attribute.Key("feature_flag.value")
+	attributeReason       = attribute.Key("feature_flag.reason")
+	attributeErrorKind    = attribute.Key("feature_flag.error_kind")
+
+	// fallbackVariant is recorded as the variant when the fallback value was
+	// served rather than one of the flag's variations.
+	fallbackVariant = "fallback"
+
+	// overrideVariant is recorded as the variant when the value was sourced
+	// from an override.
+	overrideVariant = "override"
+)
+
+// TelemetryConfig declares configuration for instrumenting flag evaluations
+// with OpenTelemetry. Provide an instance of this struct to WithTelemetry.
+type TelemetryConfig struct {
+	// MeterProvider is used to create the evaluation counters. If nil, the
+	// global MeterProvider is used.
+	MeterProvider metric.MeterProvider
+
+	// SpanEvents configures the client to add a "feature_flag" event to the
+	// span in the supplied context.Context for every evaluation, recording the
+	// flag name and result. Queries that don't accept a context.Context (the
+	// *WithEvaluationContext variants) don't record span events.
+	SpanEvents bool
+}
+
+// WithTelemetry configures the client to record OpenTelemetry metrics for
+// each flag evaluation: the number of evaluations by flag, variation and
+// reason, the number of evaluation errors by flag and error kind, and the
+// number of times the fallback value was served. Span events can optionally be
+// recorded as well; see TelemetryConfig.
+func WithTelemetry(cfg *TelemetryConfig) ConfigOption {
+	return func(c *Client) {
+		if cfg == nil {
+			cfg = &TelemetryConfig{}
+		}
+
+		c.telemetryConfig = cfg
+	}
+}
+
+// telemetry is a Hook that records flag evaluations. It is registered after
+// any hooks supplied with WithHooks, so it records the result that is returned
+// to the caller.
+type telemetry struct {
+	spanEvents bool
+
+	evaluations instrument.Int64Counter
+	errors      instrument.Int64Counter
+	fallbacks   instrument.Int64Counter
+}
+
+func newTelemetry(cfg *TelemetryConfig) (*telemetry, error) {
+	provider := cfg.MeterProvider
+	if provider == nil {
+		provider = global.MeterProvider()
+	}
+
+	meter := provider.Meter(instrumentationName)
+
+	evaluations, err := meter.Int64Counter(evaluationsCounterName, instrument.WithDescription("The number of flag evaluations, by flag, variation and reason."))
+	if err != nil {
+		return nil, fmt.Errorf("create %s counter: %w", evaluationsCounterName, err)
+	}
+
+	evalErrors, err := meter.Int64Counter(errorsCounterName, instrument.WithDescription("The number of flag evaluations that resulted in an error, by flag and error kind."))
+	if err != nil {
+		return nil, fmt.Errorf("create %s counter: %w", errorsCounterName, err)
+	}
+
+	fallbacks, err := meter.Int64Counter(fallbacksCounterName, instrument.WithDescription("The number of flag evaluations that served the fallback value, by flag."))
+	if err != nil {
+		return nil, fmt.Errorf("create %s counter: %w", fallbacksCounterName, err)
+	}
+
+	return &telemetry{
+		spanEvents:  cfg.SpanEvents,
+		evaluations: evaluations,
+		errors:      evalErrors,
+		fallbacks:   fallbacks,
+	}, nil
+}
+
+func (t *telemetry) BeforeEvaluation(ctx context.Context, _ Evaluation) context.Context {
+	return ctx
+}
+
+// AfterEvaluation records the outcome of a single flag evaluation.
+func (t *telemetry) AfterEvaluation(ctx context.Context, evaluation Evaluation, result EvaluationResult) EvaluationResult {
+	detail := result.Detail
+
+	variant := fallbackVariant
+	switch {
+	case detail.Overridden():
+		variant = overrideVariant
+	case detail.VariationIndex.IsDefined():
+		variant = strconv.Itoa(detail.VariationIndex.IntValue())
+	}
+
+	flagAttr := attributeFlagKey.String(string(evaluation.Flag))
+	reasonAttr := attributeReason.String(string(detail.Reason.GetKind()))
+
+	t.evaluations.Add(ctx, 1, flagAttr, attributeVariant.String(variant), reasonAttr)
+
+	if result.Err != nil || detail.Reason.GetKind() == ldreason.EvalReasonError {
+		t.errors.Add(ctx, 1, flagAttr, attributeErrorKind.String(errorKind(detail)))
+	}
+
+	if variant == fallbackVariant {
+		t.fallbacks.Add(ctx, 1, flagAttr)
+	}
+
+	if span := trace.SpanFromContext(ctx); t.spanEvents && span.IsRecording() {
+		span.AddEvent(spanEventName, trace.WithAttributes(
+			flagAttr,
+			attributeProviderName.String(providerName),
+			attributeVariant.String(variant),
+			attributeValue.String(ldvalue.CopyArbitraryValue(result.Value).JSONString()),
+			reasonAttr,
+		))
+	}
+
+	return result
+}
+
+func errorKind(detail EvaluationDetail) string {
+	if kind := detail.Reason.GetErrorKind(); kind != "" {
+		return string(kind)
+	}
+
+	return "UNKNOWN"
+}
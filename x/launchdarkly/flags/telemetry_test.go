@@ -0,0 +1,187 @@
+package flags
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestClientTelemetry(t *testing.T) {
+	t.Run("records evaluation metrics by flag, variation and reason", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		c := newTelemetryTestClient(t, &TelemetryConfig{
+			MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		})
+
+		evalContext := evaluationcontext.NewUser("user-id")
+		_, err := c.QueryBoolWithEvaluationContext("test-flag", evalContext, false)
+		require.NoError(t, err)
+		_, err = c.QueryBoolWithEvaluationContext("test-flag", evalContext, false)
+		require.NoError(t, err)
+
+		metrics := collectMetrics(t, reader)
+		assert.Equal(t, int64(2), counterValue(t, metrics, evaluationsCounterName,
+			attributeFlagKey.String("test-flag"),
+			attributeVariant.String("0"),
+			attributeReason.String("FALLTHROUGH"),
+		))
+		assertNoCounter(t, metrics, errorsCounterName)
+		assertNoCounter(t, metrics, fallbacksCounterName)
+	})
+
+	t.Run("records errors and fallbacks", func(t *testing.T) {
+		reader := sdkmetric.NewManualReader()
+		c := newTelemetryTestClient(t, &TelemetryConfig{
+			MeterProvider: sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)),
+		})
+
+		res, err := c.QueryStringWithEvaluationContext("missing-flag", evaluationcontext.NewUser("user-id"), "fallback")
+		require.Error(t, err)
+		assert.Equal(t, "fallback", res)
+
+		_, err = c.QueryBool(context.Background(), "test-flag", false)
+		require.Error(t, err)
+
+		metrics := collectMetrics(t, reader)
+		assert.Equal(t, int64(1), counterValue(t, metrics, errorsCounterName,
+			attributeFlagKey.String("missing-flag"),
+			attributeErrorKind.String("FLAG_NOT_FOUND"),
+		))
+		assert.Equal(t, int64(1), counterValue(t, metrics, errorsCounterName,
+			attributeFlagKey.String("test-flag"),
+			attributeErrorKind.String("USER_NOT_SPECIFIED"),
+		))
+		assert.Equal(t, int64(1), counterValue(t, metrics, fallbacksCounterName,
+			attributeFlagKey.String("missing-flag"),
+		))
+		assert.Equal(t, int64(1), counterValue(t, metrics, evaluationsCounterName,
+			attributeFlagKey.String("missing-flag"),
+			attributeVariant.String(fallbackVariant),
+			attributeReason.String("ERROR"),
+		))
+	})
+
+	t.Run("records span events when enabled", func(t *testing.T) {
+		spanRecorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)).Tracer("test")
+
+		c := newTelemetryTestClient(t, &TelemetryConfig{
+			MeterProvider: sdkmetric.NewMeterProvider(),
+			SpanEvents:    true,
+		})
+
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+			CustomerAccountID: "account-id",
+			UserID:            "user-id",
+		})
+		ctx, span := tracer.Start(ctx, "query")
+		res, err := c.QueryBool(ctx, "test-flag", false)
+		require.NoError(t, err)
+		assert.True(t, res)
+		span.End()
+
+		require.Len(t, spanRecorder.Ended(), 1)
+		events := spanRecorder.Ended()[0].Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, spanEventName, events[0].Name)
+		assert.ElementsMatch(t, []attribute.KeyValue{
+			attributeFlagKey.String("test-flag"),
+			attributeProviderName.String("LaunchDarkly"),
+			attributeVariant.String("0"),
+			attributeValue.String("true"),
+			attributeReason.String("FALLTHROUGH"),
+		}, events[0].Attributes)
+	})
+
+	t.Run("does not record span events by default", func(t *testing.T) {
+		spanRecorder := tracetest.NewSpanRecorder()
+		tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spanRecorder)).Tracer("test")
+
+		c := newTelemetryTestClient(t, &TelemetryConfig{
+			MeterProvider: sdkmetric.NewMeterProvider(),
+		})
+
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+			UserID: "user-id",
+		})
+		ctx, span := tracer.Start(ctx, "query")
+		_, err := c.QueryBool(ctx, "test-flag", false)
+		require.NoError(t, err)
+		span.End()
+
+		require.Len(t, spanRecorder.Ended(), 1)
+		assert.Empty(t, spanRecorder.Ended()[0].Events())
+	})
+}
+
+// newTelemetryTestClient returns a connected client in test mode with
+// telemetry enabled, serving true for "test-flag".
+func newTelemetryTestClient(t *testing.T, cfg *TelemetryConfig) *Client {
+	t.Helper()
+
+	c, err := NewClient(WithTestMode(nil), WithTelemetry(cfg))
+	require.NoError(t, err)
+	require.NoError(t, c.Connect())
+
+	td, err := c.TestDataSource()
+	require.NoError(t, err)
+	td.Update(td.Flag("test-flag").VariationForAllUsers(true))
+
+	return c
+}
+
+func collectMetrics(t *testing.T, reader sdkmetric.Reader) metricdata.ResourceMetrics {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	return rm
+}
+
+func findCounter(rm metricdata.ResourceMetrics, name string) (metricdata.Sum[int64], bool) {
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name == name {
+				sum, ok := m.Data.(metricdata.Sum[int64])
+				return sum, ok
+			}
+		}
+	}
+
+	return metricdata.Sum[int64]{}, false
+}
+
+func counterValue(t *testing.T, rm metricdata.ResourceMetrics, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	sum, ok := findCounter(rm, name)
+	require.True(t, ok, "counter %s not recorded", name)
+
+	want := attribute.NewSet(attrs...)
+	for _, dp := range sum.DataPoints {
+		if dp.Attributes.Equals(&want) {
+			return dp.Value
+		}
+	}
+
+	return 0
+}
+
+func assertNoCounter(t *testing.T, rm metricdata.ResourceMetrics, name string) {
+	t.Helper()
+
+	sum, ok := findCounter(rm, name)
+	if ok {
+		assert.Empty(t, sum.DataPoints, "counter %s has data points", name)
+	}
+}
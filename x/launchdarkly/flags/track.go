@@ -0,0 +1,127 @@
+package flags
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"gopkg.in/launchdarkly/go-sdk-common.v2/ldvalue"
+)
+
+// TrackedEvent is a custom event recorded by a client in test mode. See
+// TrackedEvents.
+type TrackedEvent struct {
+	// Name is the name of the event.
+	Name string
+
+	// EvaluationContext is the context the event was tracked for.
+	EvaluationContext evaluationcontext.Context
+
+	// Data is the custom data attached to the event, or nil if no data was
+	// supplied.
+	Data interface{}
+
+	// MetricValue is the numeric value of the event, or nil if the event was
+	// not tracked with TrackMetric.
+	MetricValue *float64
+}
+
+// trackedEvents collects the events tracked by a client in test mode.
+type trackedEvents struct {
+	mu     sync.Mutex
+	events []TrackedEvent
+}
+
+// Track records a custom event, typically a conversion event for an
+// experiment. User attributes are extracted from the context. In test mode,
+// the event is not sent to LaunchDarkly; it is recorded and can be inspected
+// with TrackedEvents.
+func (c *Client) Track(ctx context.Context, eventName string) error {
+	user, err := evaluationcontext.UserFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("get user from context: %w", err)
+	}
+
+	return c.TrackWithEvaluationContext(eventName, user)
+}
+
+// TrackWithEvaluationContext records a custom event. An evaluation context
+// must be supplied manually.
+func (c *Client) TrackWithEvaluationContext(eventName string, evalContext evaluationcontext.Context) error {
+	if c.mode == modeTest {
+		c.recordTrackedEvent(TrackedEvent{Name: eventName, EvaluationContext: evalContext})
+		return nil
+	}
+
+	return c.wrappedClient.TrackEvent(eventName, evalContext.ToLDUser())
+}
+
+// TrackWithData records a custom event with additional data. The data may be
+// any value that can be marshalled to JSON. User attributes are extracted from
+// the context.
+func (c *Client) TrackWithData(ctx context.Context, eventName string, data interface{}) error {
+	user, err := evaluationcontext.UserFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("get user from context: %w", err)
+	}
+
+	return c.TrackWithDataAndEvaluationContext(eventName, user, data)
+}
+
+// TrackWithDataAndEvaluationContext records a custom event with additional
+// data. An evaluation context must be supplied manually.
+func (c *Client) TrackWithDataAndEvaluationContext(eventName string, evalContext evaluationcontext.Context, data interface{}) error {
+	if c.mode == modeTest {
+		c.recordTrackedEvent(TrackedEvent{Name: eventName, EvaluationContext: evalContext, Data: data})
+		return nil
+	}
+
+	return c.wrappedClient.TrackData(eventName, evalContext.ToLDUser(), ldvalue.CopyArbitraryValue(data))
+}
+
+// TrackMetric records a custom event with a numeric value, for use with
+// numeric metrics in experiments, along with optional additional data (which
+// may be nil). User attributes are extracted from the context.
+func (c *Client) TrackMetric(ctx context.Context, eventName string, metricValue float64, data interface{}) error {
+	user, err := evaluationcontext.UserFromContext(ctx)
+	if err != nil {
+		return fmt.Errorf("get user from context: %w", err)
+	}
+
+	return c.TrackMetricWithEvaluationContext(eventName, user, metricValue, data)
+}
+
+// TrackMetricWithEvaluationContext records a custom event with a numeric
+// value and optional additional data. An evaluation context must be supplied
+// manually.
+func (c *Client) TrackMetricWithEvaluationContext(eventName string, evalContext evaluationcontext.Context, metricValue float64, data interface{}) error {
+	if c.mode == modeTest {
+		c.recordTrackedEvent(TrackedEvent{Name: eventName, EvaluationContext: evalContext, Data: data, MetricValue: &metricValue})
+		return nil
+	}
+
+	return c.wrappedClient.TrackMetric(eventName, evalContext.ToLDUser(), metricValue, ldvalue.CopyArbitraryValue(data))
+}
+
+// TrackedEvents returns the events recorded by the Track methods, in the order
+// they were tracked. An error is returned if the client wasn't configured in
+// test mode.
+func (c *Client) TrackedEvents() ([]TrackedEvent, error) {
+	if c.mode != modeTest {
+		return nil, errors.New("tracked events are only recorded in test mode")
+	}
+
+	c.tracked.mu.Lock()
+	defer c.tracked.mu.Unlock()
+
+	return append([]TrackedEvent(nil), c.tracked.events...), nil
+}
+
+func (c *Client) recordTrackedEvent(event TrackedEvent) {
+	c.tracked.mu.Lock()
+	defer c.tracked.mu.Unlock()
+
+	c.tracked.events = append(c.tracked.events, event)
+}
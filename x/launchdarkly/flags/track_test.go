@@ -0,0 +1,74 @@
+package flags
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/launchdarkly/flags/evaluationcontext"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientTrack(t *testing.T) {
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+		CustomerAccountID: "account-id",
+		UserID:            "user-id",
+	})
+
+	t.Run("records tracked events in test mode", func(t *testing.T) {
+		c, err := NewClient(WithTestMode(nil))
+		require.NoError(t, err)
+		require.NoError(t, c.Connect())
+
+		require.NoError(t, c.Track(ctx, "clicked"))
+		require.NoError(t, c.TrackWithData(ctx, "purchased", map[string]string{"plan": "premium"}))
+		require.NoError(t, c.TrackMetric(ctx, "checkout-time", 12.5, nil))
+		require.NoError(t, c.TrackWithEvaluationContext("viewed", evaluationcontext.NewUser("other-user-id")))
+
+		events, err := c.TrackedEvents()
+		require.NoError(t, err)
+		require.Len(t, events, 4)
+
+		assert.Equal(t, "clicked", events[0].Name)
+		assert.Equal(t, "user-id", events[0].EvaluationContext.ToLDUser().GetKey())
+		assert.Equal(t, "account-id", events[0].EvaluationContext.ToLDUser().GetAttribute("accountID").StringValue())
+		assert.Nil(t, events[0].Data)
+		assert.Nil(t, events[0].MetricValue)
+
+		assert.Equal(t, "purchased", events[1].Name)
+		assert.Equal(t, map[string]string{"plan": "premium"}, events[1].Data)
+
+		assert.Equal(t, "checkout-time", events[2].Name)
+		require.NotNil(t, events[2].MetricValue)
+		assert.Equal(t, 12.5, *events[2].MetricValue)
+
+		assert.Equal(t, "viewed", events[3].Name)
+		assert.Equal(t, "other-user-id", events[3].EvaluationContext.ToLDUser().GetKey())
+	})
+
+	t.Run("errors if the context has no user", func(t *testing.T) {
+		c, err := NewClient(WithTestMode(nil))
+		require.NoError(t, err)
+
+		require.Error(t, c.Track(context.Background(), "clicked"))
+		require.Error(t, c.TrackWithData(context.Background(), "clicked", nil))
+		require.Error(t, c.TrackMetric(context.Background(), "clicked", 1, nil))
+
+		events, err := c.TrackedEvents()
+		require.NoError(t, err)
+		assert.Empty(t, events)
+	})
+
+	t.Run("returns an error when getting tracked events if not configured in test mode", func(t *testing.T) {
+		os.Setenv(configurationEnvVar, validConfigJSON)
+		defer os.Unsetenv(configurationEnvVar)
+
+		c, err := NewClient()
+		require.NoError(t, err)
+
+		_, err = c.TrackedEvents()
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,28 @@
+// Package middleware composes the ca-go middleware into a single stack, so
+// that every service applies it in the same order.
+//
+// The order matters: errorreport tags the Sentry scope with the request IDs,
+// user and request metadata in the context when the request starts, so it
+// must run after the middleware that adds them. Likewise, flags evaluated with
+// the request context use the AuthenticatedUser and RequestMetadata added by
+// the stack.
+//
+// NewHTTP returns HTTP middleware. Each layer can be enabled, disabled or
+// configured with options, and the service provides its own middleware for
+// authenticating users:
+//   stack := middleware.NewHTTP(
+//     middleware.WithAuthentication(jwtAuthentication),
+//     middleware.WithImpersonationPolicy(
+//       request.WithBlockedRoutes("DELETE /accounts/"),
+//       request.WithImpersonationAuditor(audit.DefaultRecorder().ImpersonationAuditor())),
+//   )
+//
+//   http.ListenAndServe(":8080", stack(mux))
+//
+// NewLambda and NewLambdaWithOutput return the equivalent middleware for
+// Lambda functions. The request attributes carried by the event can be added
+// to the context by a ContextExtractor:
+//   stack := middleware.NewLambda(extractFromMessage, middleware.WithAuditFlush())
+//
+//   lambda.Start(stack(handler))
+package middleware
@@ -0,0 +1,28 @@
+package middleware_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/cultureamp/ca-go/x/middleware"
+	"github.com/cultureamp/ca-go/x/request"
+)
+
+func ExampleNewHTTP() {
+	stack := middleware.NewHTTP(
+		middleware.WithAuthentication(authentication),
+		middleware.WithImpersonationPolicy(request.WithBlockedRoutes("DELETE /accounts/")),
+	)
+
+	handler := stack(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := request.AuthenticatedUserFromContext(r.Context())
+		fmt.Println("user:", user.UserID)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/accounts/123", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Output:
+	// user: 456
+}
@@ -0,0 +1,169 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/cultureamp/ca-go/x/sentry/errorreport"
+)
+
+type httpConfig struct {
+	requestIDs         bool
+	requestIDOptions   []request.ExtractOption
+	requestMetadata    bool
+	metadataOptions    []request.MetadataOption
+	authentication     func(http.Handler) http.Handler
+	serviceAuth        []request.ServiceAuthenticationOption
+	serviceAuthEnabled bool
+	impersonation      []request.ImpersonationOption
+	impersonationOn    bool
+	errorReporting     bool
+	onPanic            errorreport.OnRequestPanicHandler
+}
+
+// HTTPOption is a function type that can be provided to NewHTTP to enable,
+// disable or configure the layers of the middleware stack.
+type HTTPOption func(c *httpConfig)
+
+// WithoutRequestIDs disables the layer that adds RequestIDs to the request
+// context.
+func WithoutRequestIDs() HTTPOption {
+	return func(c *httpConfig) {
+		c.requestIDs = false
+	}
+}
+
+// WithRequestIDOptions configures how the incoming X-Request-Id and
+// X-Correlation-Id headers are checked. They are validated with the default
+// rules unless other rules are given with request.WithValidation, or
+// validation is turned off with request.WithoutValidation.
+func WithRequestIDOptions(opts ...request.ExtractOption) HTTPOption {
+	return func(c *httpConfig) {
+		c.requestIDOptions = opts
+	}
+}
+
+// WithoutRequestMetadata disables the layer that adds RequestMetadata to the
+// request context.
+func WithoutRequestMetadata() HTTPOption {
+	return func(c *httpConfig) {
+		c.requestMetadata = false
+	}
+}
+
+// WithRequestMetadataOptions configures how RequestMetadata is read, for
+// example with request.WithTrustedProxies for a service behind a load
+// balancer.
+func WithRequestMetadataOptions(opts ...request.MetadataOption) HTTPOption {
+	return func(c *httpConfig) {
+		c.metadataOptions = opts
+	}
+}
+
+// WithAuthentication adds the service's own middleware that authenticates the
+// user, for example by verifying a JWT, and adds the AuthenticatedUser to the
+// request context.
+func WithAuthentication(mw func(http.Handler) http.Handler) HTTPOption {
+	return func(c *httpConfig) {
+		c.authentication = mw
+	}
+}
+
+// WithServiceAuthentication enables the layer that authenticates calling
+// services, configured by the options. See
+// request.NewAuthenticatedServiceHTTPMiddleware.
+func WithServiceAuthentication(opts ...request.ServiceAuthenticationOption) HTTPOption {
+	return func(c *httpConfig) {
+		c.serviceAuthEnabled = true
+		c.serviceAuth = opts
+	}
+}
+
+// WithImpersonationPolicy enables the layer that enforces the impersonation
+// policy configured by the options. See request.NewImpersonationHTTPMiddleware.
+func WithImpersonationPolicy(opts ...request.ImpersonationOption) HTTPOption {
+	return func(c *httpConfig) {
+		c.impersonationOn = true
+		c.impersonation = opts
+	}
+}
+
+// WithoutErrorReporting disables the layer that reports panics to Sentry.
+func WithoutErrorReporting() HTTPOption {
+	return func(c *httpConfig) {
+		c.errorReporting = false
+	}
+}
+
+// WithPanicHandler configures the handler that writes the response after a
+// panic is reported. See errorreport.NewHTTPMiddleware.
+func WithPanicHandler(handler errorreport.OnRequestPanicHandler) HTTPOption {
+	return func(c *httpConfig) {
+		c.onPanic = handler
+	}
+}
+
+// NewHTTP returns HTTP middleware composed of the ca-go middleware, applied in
+// this order:
+//  1. request.NewRequestIDHTTPMiddleware adds RequestIDs.
+//  2. request.NewRequestMetadataHTTPMiddleware adds RequestMetadata.
+//  3. request.NewAuthenticatedServiceHTTPMiddleware authenticates calling
+//     services, if enabled by WithServiceAuthentication.
+//  4. The middleware supplied by WithAuthentication adds the
+//     AuthenticatedUser, if any.
+//  5. request.NewImpersonationHTTPMiddleware enforces the impersonation
+//     policy, if enabled by WithImpersonationPolicy.
+//  6. errorreport.NewHTTPMiddleware reports panics to Sentry.
+//
+// Error reporting is innermost so that the Sentry scope is tagged with the
+// request IDs, user and metadata added by the other layers. For the same
+// reason, flags queried with the request context are evaluated for the
+// authenticated user and request metadata. errorreport.NewHTTPRecoverMiddleware
+// is also applied outside all the other layers, so that panics in those
+// layers, such as in the authentication middleware, are recovered and
+// reported too, though without the request attributes.
+func NewHTTP(opts ...HTTPOption) func(http.Handler) http.Handler {
+	cfg := &httpConfig{
+		requestIDs:      true,
+		requestMetadata: true,
+		errorReporting:  true,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	var layers []func(http.Handler) http.Handler
+
+	if cfg.requestIDs {
+		layers = append(layers, request.NewRequestIDHTTPMiddleware(cfg.requestIDOptions...))
+	}
+
+	if cfg.requestMetadata {
+		layers = append(layers, request.NewRequestMetadataHTTPMiddleware(cfg.metadataOptions...))
+	}
+
+	if cfg.serviceAuthEnabled {
+		layers = append(layers, request.NewAuthenticatedServiceHTTPMiddleware(cfg.serviceAuth...))
+	}
+
+	if cfg.authentication != nil {
+		layers = append(layers, cfg.authentication)
+	}
+
+	if cfg.impersonationOn {
+		layers = append(layers, request.NewImpersonationHTTPMiddleware(cfg.impersonation...))
+	}
+
+	if cfg.errorReporting {
+		layers = append(layers, errorreport.NewHTTPMiddleware(cfg.onPanic))
+		layers = append([]func(http.Handler) http.Handler{errorreport.NewHTTPRecoverMiddleware(cfg.onPanic)}, layers...)
+	}
+
+	return func(next http.Handler) http.Handler {
+		for i := len(layers) - 1; i >= 0; i-- {
+			next = layers[i](next)
+		}
+
+		return next
+	}
+}
@@ -0,0 +1,160 @@
+package middleware_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/internal/sentrytest"
+	"github.com/cultureamp/ca-go/x/middleware"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthenticatedUser() request.AuthenticatedUser {
+	return request.AuthenticatedUser{
+		CustomerAccountID: "123",
+		UserID:            "456",
+		RealUserID:        "789",
+	}
+}
+
+// authentication adds the same AuthenticatedUser to every request.
+func authentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := request.ContextWithAuthenticatedUser(r.Context(), newAuthenticatedUser())
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func TestNewHTTP(t *testing.T) {
+	t.Run("populates the request context", func(t *testing.T) {
+		var r *http.Request
+		handler := middleware.NewHTTP(middleware.WithAuthentication(authentication))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+			}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(request.RequestIDKey, "abc")
+		req.Header.Set("Accept-Language", "fr-FR")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		ids, ok := request.RequestIDsFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, "abc", ids.RequestID)
+		assert.Equal(t, "abc", rec.Header().Get(request.RequestIDKey))
+
+		metadata, ok := request.RequestMetadataFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, "fr-FR", metadata.Locale)
+
+		user, ok := request.AuthenticatedUserFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, newAuthenticatedUser(), user)
+	})
+
+	t.Run("configures request metadata", func(t *testing.T) {
+		var r *http.Request
+		handler := middleware.NewHTTP(middleware.WithRequestMetadataOptions(request.WithTrustedProxies(1)))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				r = req
+			}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Forwarded-For", "10.1.1.1, 203.0.113.7")
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		metadata, ok := request.RequestMetadataFromContext(r.Context())
+		require.True(t, ok)
+		assert.Equal(t, "203.0.113.7", metadata.SourceIP)
+	})
+
+	t.Run("reports panics with the request context", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		handler := middleware.NewHTTP(middleware.WithAuthentication(authentication))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				panic("boom")
+			}))
+
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(request.RequestIDKey, "abc")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Len(t, mockSentryTransport.Events(), 1)
+		event := mockSentryTransport.Events()[0]
+		assert.Equal(t, "abc", event.Tags["RequestID"])
+		assert.Equal(t, "123", event.Tags["customer"])
+		assert.Equal(t, "456", event.User.ID)
+	})
+
+	t.Run("reports panics in the other layers", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		panickingAuthentication := func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("authentication failed")
+			})
+		}
+
+		called := false
+		handler := middleware.NewHTTP(middleware.WithAuthentication(panickingAuthentication))(
+			http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				called = true
+			}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.False(t, called)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.Len(t, mockSentryTransport.Events(), 1)
+		assert.Equal(t, "authentication failed", mockSentryTransport.Events()[0].Exception[0].Value)
+	})
+
+	t.Run("disables layers", func(t *testing.T) {
+		var r *http.Request
+		handler := middleware.NewHTTP(
+			middleware.WithoutRequestIDs(),
+			middleware.WithoutRequestMetadata(),
+			middleware.WithoutErrorReporting(),
+		)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			r = req
+		}))
+
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.False(t, request.ContextHasRequestIDs(r.Context()))
+		assert.False(t, request.ContextHasRequestMetadata(r.Context()))
+	})
+
+	t.Run("enforces the impersonation policy", func(t *testing.T) {
+		called := false
+		handler := middleware.NewHTTP(
+			middleware.WithAuthentication(authentication),
+			middleware.WithImpersonationPolicy(request.WithBlockedRoutes("DELETE /accounts/")),
+		)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			called = true
+		}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/accounts/123", nil))
+
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		assert.False(t, called)
+	})
+
+	t.Run("requires service authentication", func(t *testing.T) {
+		handler := middleware.NewHTTP(
+			middleware.WithServiceAuthentication(request.WithServiceAuthenticationRequired()),
+		)(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {}))
+
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
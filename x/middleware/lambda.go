@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/lambdafunction"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/cultureamp/ca-go/x/sentry/errorreport"
+)
+
+// ContextExtractor returns a copy of the context with the request attributes
+// carried by the event, for example with request.Extract on the attributes of
+// an SQS message.
+type ContextExtractor[TIn any] func(ctx context.Context, event TIn) context.Context
+
+type lambdaConfig struct {
+	requestIDs     bool
+	auditFlush     bool
+	errorReporting bool
+	errorOptions   []errorreport.LambdaOption
+}
+
+// LambdaOption is a function type that can be provided to NewLambda and
+// NewLambdaWithOutput to enable, disable or configure the layers of the
+// middleware stack.
+type LambdaOption func(c *lambdaConfig)
+
+// WithoutLambdaRequestIDs disables the layer that adds RequestIDs to the
+// context of invocations whose context has none.
+func WithoutLambdaRequestIDs() LambdaOption {
+	return func(c *lambdaConfig) {
+		c.requestIDs = false
+	}
+}
+
+// WithAuditFlush enables the layer that flushes the default audit Recorder at
+// the end of each invocation, before the execution environment can be
+// frozen. Errors from the flush are reported to Sentry and do not fail the
+// invocation.
+func WithAuditFlush() LambdaOption {
+	return func(c *lambdaConfig) {
+		c.auditFlush = true
+	}
+}
+
+// WithoutLambdaErrorReporting disables the layer that reports errors and
+// panics to Sentry.
+func WithoutLambdaErrorReporting() LambdaOption {
+	return func(c *lambdaConfig) {
+		c.errorReporting = false
+	}
+}
+
+// WithErrorReportOptions configures the error reporting layer. See
+// errorreport.LambdaMiddleware.
+func WithErrorReportOptions(opts ...errorreport.LambdaOption) LambdaOption {
+	return func(c *lambdaConfig) {
+		c.errorOptions = opts
+	}
+}
+
+// NewLambda returns middleware for a Lambda function with a payload of TIn,
+// composed of the ca-go middleware and applied in this order:
+//  1. extract, if not nil, adds the request attributes carried by the event.
+//  2. RequestIDs are added if the context still has none. The request ID and
+//     correlation ID are the AWS request ID of the invocation.
+//  3. The default audit Recorder is flushed after the invocation, if enabled
+//     by WithAuditFlush.
+//  4. errorreport.LambdaMiddleware reports errors and panics to Sentry.
+//
+// As with NewHTTP, error reporting is innermost so that reports include the
+// request attributes added by the other layers. The result can be composed
+// with other middleware by lambdafunction.Chain.
+func NewLambda[TIn any](extract ContextExtractor[TIn], opts ...LambdaOption) lambdafunction.Middleware[TIn] {
+	cfg := newLambdaConfig(opts)
+
+	layers := []lambdafunction.Middleware[TIn]{contextLayer(cfg, extract)}
+
+	if cfg.auditFlush {
+		layers = append(layers, auditFlushLayer[TIn]())
+	}
+
+	if cfg.errorReporting {
+		layers = append(layers, errorreport.NewLambdaMiddleware[TIn](cfg.errorOptions...))
+	}
+
+	return lambdafunction.Chain(layers...)
+}
+
+// NewLambdaWithOutput returns middleware for a Lambda function with a payload
+// of TIn and an output of TOut, composed in the same way as NewLambda.
+func NewLambdaWithOutput[TIn any, TOut any](extract ContextExtractor[TIn], opts ...LambdaOption) lambdafunction.MiddlewareWithOutput[TIn, TOut] {
+	cfg := newLambdaConfig(opts)
+
+	layers := []lambdafunction.MiddlewareWithOutput[TIn, TOut]{
+		lambdafunction.MiddlewareForOutput[TIn, TOut](contextLayer(cfg, extract)),
+	}
+
+	if cfg.auditFlush {
+		layers = append(layers, lambdafunction.MiddlewareForOutput[TIn, TOut](auditFlushLayer[TIn]()))
+	}
+
+	if cfg.errorReporting {
+		layers = append(layers, errorreport.NewLambdaWithOutputMiddleware[TIn, TOut](cfg.errorOptions...))
+	}
+
+	return lambdafunction.ChainWithOutput(layers...)
+}
+
+func newLambdaConfig(opts []LambdaOption) *lambdaConfig {
+	cfg := &lambdaConfig{
+		requestIDs:     true,
+		errorReporting: true,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// contextLayer adds the request attributes extracted from the event and the
+// invocation's RequestIDs to the context.
+func contextLayer[TIn any](c *lambdaConfig, extract ContextExtractor[TIn]) lambdafunction.Middleware[TIn] {
+	return func(next lambdafunction.HandlerOf[TIn]) lambdafunction.HandlerOf[TIn] {
+		return func(ctx context.Context, event TIn) error {
+			if extract != nil {
+				ctx = extract(ctx, event)
+			}
+
+			if c.requestIDs && !request.ContextHasRequestIDs(ctx) {
+				ctx = request.ContextWithRequestIDs(ctx, invocationRequestIDs(ctx))
+			}
+
+			return next(ctx, event)
+		}
+	}
+}
+
+// auditFlushLayer flushes the default audit Recorder after the handler
+// returns.
+func auditFlushLayer[TIn any]() lambdafunction.Middleware[TIn] {
+	return func(next lambdafunction.HandlerOf[TIn]) lambdafunction.HandlerOf[TIn] {
+		return func(ctx context.Context, event TIn) error {
+			defer func() {
+				if err := audit.Flush(ctx); err != nil {
+					errorreport.ReportError(ctx, fmt.Errorf("flush audit events: %w", err))
+				}
+			}()
+
+			return next(ctx, event)
+		}
+	}
+}
+
+// invocationRequestIDs returns RequestIDs for the Lambda invocation, using
+// the AWS request ID if it is available.
+func invocationRequestIDs(ctx context.Context) request.RequestIDs {
+	id := request.NewID()
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		id = lc.AwsRequestID
+	}
+
+	return request.RequestIDs{
+		RequestID:     id,
+		CorrelationID: id,
+	}
+}
@@ -0,0 +1,121 @@
+package middleware_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/cultureamp/ca-go/x/audit"
+	"github.com/cultureamp/ca-go/x/audit/audittest"
+	"github.com/cultureamp/ca-go/x/internal/sentrytest"
+	"github.com/cultureamp/ca-go/x/middleware"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type message struct {
+	UserID string
+}
+
+func extractUser(ctx context.Context, msg message) context.Context {
+	return request.ContextWithAuthenticatedUser(ctx, request.AuthenticatedUser{UserID: msg.UserID})
+}
+
+func TestNewLambda(t *testing.T) {
+	lambdaCtx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "aws-123"})
+
+	t.Run("populates the context", func(t *testing.T) {
+		var ctx context.Context
+		handler := middleware.NewLambda(extractUser)(func(c context.Context, msg message) error {
+			ctx = c
+			return nil
+		})
+
+		require.NoError(t, handler(lambdaCtx, message{UserID: "456"}))
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, request.RequestIDs{RequestID: "aws-123", CorrelationID: "aws-123"}, ids)
+
+		user, ok := request.AuthenticatedUserFromContext(ctx)
+		require.True(t, ok)
+		assert.Equal(t, "456", user.UserID)
+	})
+
+	t.Run("keeps extracted request IDs", func(t *testing.T) {
+		extracted := request.RequestIDs{RequestID: "abc", CorrelationID: "def"}
+		extract := func(ctx context.Context, msg message) context.Context {
+			return request.ContextWithRequestIDs(ctx, extracted)
+		}
+
+		var ids request.RequestIDs
+		handler := middleware.NewLambda(extract)(func(c context.Context, msg message) error {
+			ids, _ = request.RequestIDsFromContext(c)
+			return nil
+		})
+
+		require.NoError(t, handler(lambdaCtx, message{}))
+		assert.Equal(t, extracted, ids)
+	})
+
+	t.Run("reports errors with the context", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+		handler := middleware.NewLambda(extractUser)(func(c context.Context, msg message) error {
+			return errors.New("failed")
+		})
+
+		assert.Error(t, handler(lambdaCtx, message{UserID: "456"}))
+
+		require.Len(t, mockSentryTransport.Events(), 1)
+		event := mockSentryTransport.Events()[0]
+		assert.Equal(t, "aws-123", event.Tags["RequestID"])
+		assert.Equal(t, "456", event.User.ID)
+	})
+
+	t.Run("disables layers", func(t *testing.T) {
+		mockSentryTransport := sentrytest.Init(t)
+
+		var ctx context.Context
+		handler := middleware.NewLambda[message](nil,
+			middleware.WithoutLambdaRequestIDs(),
+			middleware.WithoutLambdaErrorReporting(),
+		)(func(c context.Context, msg message) error {
+			ctx = c
+			return errors.New("failed")
+		})
+
+		assert.Error(t, handler(lambdaCtx, message{}))
+		assert.False(t, request.ContextHasRequestIDs(ctx))
+		assert.Empty(t, mockSentryTransport.Events())
+	})
+
+	t.Run("flushes audit events", func(t *testing.T) {
+		sink := audittest.NewSink()
+		require.NoError(t, audit.Configure(audit.WithSink(sink), audit.WithFlushInterval(0)))
+		t.Cleanup(func() { _ = audit.Close(context.Background()) })
+
+		handler := middleware.NewLambda(extractUser, middleware.WithAuditFlush())(func(c context.Context, msg message) error {
+			return audit.Record(c, "survey.delete", "survey-1", nil)
+		})
+
+		require.NoError(t, handler(lambdaCtx, message{UserID: "456"}))
+
+		events := sink.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "456", events[0].Actor.UserID)
+		assert.Equal(t, "aws-123", events[0].RequestID)
+	})
+}
+
+func TestNewLambdaWithOutput(t *testing.T) {
+	handler := middleware.NewLambdaWithOutput[message, string](extractUser)(func(ctx context.Context, msg message) (string, error) {
+		user, _ := request.AuthenticatedUserFromContext(ctx)
+		return user.UserID, nil
+	})
+
+	out, err := handler(context.Background(), message{UserID: "456"})
+	require.NoError(t, err)
+	assert.Equal(t, "456", out)
+}
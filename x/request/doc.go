@@ -3,5 +3,15 @@
 //
 // Request-scoped attributes include identifiers like the request and correlation
 // IDs. When the request is authenticated, user identifiers like the account and
-// user aggregate IDs can also be added to the context.
+// user aggregate IDs can also be added to the context. Requests made by other
+// services carry an AuthenticatedService instead, and descriptive attributes
+// supplied by the client, like its locale and time zone, are held in
+// RequestMetadata.
+//
+// HTTP middleware adds these attributes to each request, and guards routes
+// that may not be used while a user is impersonated. Inject and Extract
+// propagate them across process boundaries, such as HTTP calls, message
+// queues, gRPC calls and OpenTelemetry trace context. Identifiers read from a
+// caller can be validated, so that malformed values don't flow into logs,
+// Sentry and LaunchDarkly.
 package request
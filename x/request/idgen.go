@@ -0,0 +1,121 @@
+package request
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IDGenerator generates unique identifiers, such as request IDs and the keys
+// of anonymous users.
+type IDGenerator interface {
+	NewID() string
+}
+
+// IDGeneratorFunc adapts a function to an IDGenerator.
+type IDGeneratorFunc func() string
+
+// NewID implements IDGenerator.
+func (f IDGeneratorFunc) NewID() string {
+	return f()
+}
+
+var (
+	// UUIDv4 generates random version 4 UUIDs. It is the default
+	// IDGenerator.
+	UUIDv4 IDGenerator = IDGeneratorFunc(uuid.NewString)
+
+	// UUIDv7 generates version 7 UUIDs, which start with a millisecond
+	// timestamp so that IDs sort in the order they were generated. Use it
+	// when IDs are stored in indexes that benefit from locality.
+	UUIDv7 IDGenerator = IDGeneratorFunc(newUUIDv7)
+
+	// ULID generates ULIDs: 26-character, case-insensitive identifiers that
+	// start with a millisecond timestamp, so that IDs sort in the order they
+	// were generated.
+	ULID IDGenerator = IDGeneratorFunc(newULID)
+)
+
+var (
+	idGeneratorMu sync.RWMutex
+	idGenerator   = UUIDv4
+)
+
+// SetIDGenerator sets the IDGenerator used by NewID throughout the process,
+// including by the request ID middleware and when creating anonymous users.
+// Call it once at startup, before any IDs are generated. Passing nil restores
+// the default, UUIDv4.
+func SetIDGenerator(g IDGenerator) {
+	if g == nil {
+		g = UUIDv4
+	}
+
+	idGeneratorMu.Lock()
+	defer idGeneratorMu.Unlock()
+
+	idGenerator = g
+}
+
+// NewID returns a new identifier from the IDGenerator set by SetIDGenerator.
+func NewID() string {
+	idGeneratorMu.RLock()
+	g := idGenerator
+	idGeneratorMu.RUnlock()
+
+	return g.NewID()
+}
+
+// newUUIDv7 returns a version 7 UUID as defined by RFC 9562: a 48-bit Unix
+// timestamp in milliseconds followed by random bits.
+func newUUIDv7() string {
+	var id uuid.UUID
+	randomBytes(id[6:])
+
+	putTimestamp(id[:6], time.Now())
+	id[6] = id[6]&0x0f | 0x70 // version 7
+	id[8] = id[8]&0x3f | 0x80 // RFC 9562 variant
+
+	return id.String()
+}
+
+// crockfordBase32 is the alphabet used to encode ULIDs.
+const crockfordBase32 = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// newULID returns a ULID: a 48-bit Unix timestamp in milliseconds followed by
+// 80 random bits, encoded as 26 characters of Crockford's base 32.
+func newULID() string {
+	var id [16]byte
+	putTimestamp(id[:6], time.Now())
+	randomBytes(id[6:])
+
+	// Encode the 128 bits five at a time, most significant first. The first
+	// character holds only the top three bits, as 26 characters hold 130.
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockfordBase32[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+
+	return string(out[:])
+}
+
+func putTimestamp(b []byte, t time.Time) {
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(b, ms[2:])
+}
+
+func randomBytes(b []byte) {
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand only fails if the operating system's source of
+		// randomness is unavailable, in which case no ID is safe to use.
+		panic("request: read random bytes: " + err.Error())
+	}
+}
@@ -0,0 +1,118 @@
+package request_test
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+
+func TestIDGenerators(t *testing.T) {
+	t.Run("UUIDv4", func(t *testing.T) {
+		id, err := uuid.Parse(request.UUIDv4.NewID())
+		require.NoError(t, err)
+		assert.Equal(t, uuid.Version(4), id.Version())
+	})
+
+	t.Run("UUIDv7", func(t *testing.T) {
+		before := time.Now().UnixMilli()
+		id, err := uuid.Parse(request.UUIDv7.NewID())
+		require.NoError(t, err)
+		after := time.Now().UnixMilli()
+
+		assert.Equal(t, uuid.Version(7), id.Version())
+		assert.Equal(t, uuid.RFC4122, id.Variant())
+
+		ms := int64(id[0])<<40 | int64(id[1])<<32 | int64(id[2])<<24 | int64(id[3])<<16 | int64(id[4])<<8 | int64(id[5])
+		assert.GreaterOrEqual(t, ms, before)
+		assert.LessOrEqual(t, ms, after)
+	})
+
+	t.Run("ULID", func(t *testing.T) {
+		id := request.ULID.NewID()
+		assert.Regexp(t, ulidPattern, id)
+	})
+
+	for name, g := range map[string]request.IDGenerator{"UUIDv7": request.UUIDv7, "ULID": request.ULID} {
+		g := g
+		t.Run(name+" is time-ordered", func(t *testing.T) {
+			var ids []string
+			for i := 0; i < 3; i++ {
+				ids = append(ids, g.NewID())
+				time.Sleep(2 * time.Millisecond)
+			}
+
+			assert.True(t, sort.StringsAreSorted(ids), ids)
+		})
+	}
+
+	for name, g := range map[string]request.IDGenerator{"UUIDv4": request.UUIDv4, "UUIDv7": request.UUIDv7, "ULID": request.ULID} {
+		g := g
+		t.Run(name+" is unique", func(t *testing.T) {
+			seen := map[string]bool{}
+			for i := 0; i < 1000; i++ {
+				id := g.NewID()
+				require.False(t, seen[id], id)
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestULIDEncoding(t *testing.T) {
+	// The first 10 characters encode the millisecond timestamp.
+	id := request.ULID.NewID()
+	require.Len(t, id, 26)
+
+	decoded := int64(0)
+	for _, c := range id[:10] {
+		decoded = decoded<<5 | int64(indexOf(t, c))
+	}
+	assert.InDelta(t, time.Now().UnixMilli(), decoded, 1000)
+}
+
+func indexOf(t *testing.T, c rune) int {
+	t.Helper()
+
+	const alphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	for i, a := range alphabet {
+		if a == c {
+			return i
+		}
+	}
+
+	t.Fatalf("invalid ULID character %q", c)
+	return 0
+}
+
+func TestSetIDGenerator(t *testing.T) {
+	t.Cleanup(func() { request.SetIDGenerator(nil) })
+
+	request.SetIDGenerator(request.IDGeneratorFunc(func() string { return "fixed" }))
+	assert.Equal(t, "fixed", request.NewID())
+
+	request.SetIDGenerator(request.ULID)
+	assert.Regexp(t, ulidPattern, request.NewID())
+
+	request.SetIDGenerator(nil)
+	_, err := uuid.Parse(request.NewID())
+	assert.NoError(t, err)
+}
+
+func ExampleSetIDGenerator() {
+	request.SetIDGenerator(request.ULID)
+	defer request.SetIDGenerator(nil)
+
+	fmt.Println(len(request.NewID()))
+
+	// Output:
+	// 26
+}
@@ -0,0 +1,216 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+)
+
+// ErrImpersonationNotAllowed is returned when an impersonated request
+// attempts an operation that must be performed by the real user.
+var ErrImpersonationNotAllowed = errors.New("operation not allowed while impersonating")
+
+// IsImpersonated returns whether the user is being impersonated, which is
+// when a RealUserID is present and differs from the UserID.
+func (u AuthenticatedUser) IsImpersonated() bool {
+	return u.RealUserID != "" && u.RealUserID != u.UserID
+}
+
+// IsImpersonatedRequest returns whether the context contains an
+// AuthenticatedUser that is being impersonated.
+func IsImpersonatedRequest(ctx context.Context) bool {
+	user, ok := AuthenticatedUserFromContext(ctx)
+	return ok && user.IsImpersonated()
+}
+
+// DenyImpersonation returns ErrImpersonationNotAllowed if the request is
+// impersonated. Call it at the start of operations that only the real user
+// may perform, such as changing a password:
+//   if err := request.DenyImpersonation(ctx); err != nil {
+//     return nil, err
+//   }
+func DenyImpersonation(ctx context.Context) error {
+	if IsImpersonatedRequest(ctx) {
+		return ErrImpersonationNotAllowed
+	}
+
+	return nil
+}
+
+// ImpersonationEvent describes an operation performed by an impersonated
+// request, for recording in an audit trail.
+type ImpersonationEvent struct {
+	// Time is when the operation was performed.
+	Time time.Time
+	// Operation identifies the operation, for example "POST /surveys".
+	Operation string
+	// User is the impersonated user, whose RealUserID is the user who
+	// performed the operation.
+	User AuthenticatedUser
+	// RequestIDs are the identifiers of the request, if present.
+	RequestIDs RequestIDs
+}
+
+// NewImpersonationEvent returns an ImpersonationEvent for the operation, with
+// the AuthenticatedUser and RequestIDs in the context. The boolean is false
+// if the request is not impersonated.
+func NewImpersonationEvent(ctx context.Context, operation string) (ImpersonationEvent, bool) {
+	user, ok := AuthenticatedUserFromContext(ctx)
+	if !ok || !user.IsImpersonated() {
+		return ImpersonationEvent{}, false
+	}
+
+	ids, _ := RequestIDsFromContext(ctx)
+
+	return ImpersonationEvent{
+		Time:       time.Now().UTC(),
+		Operation:  operation,
+		User:       user,
+		RequestIDs: ids,
+	}, true
+}
+
+// ImpersonationAuditor records an ImpersonationEvent, for example by writing
+// it to an audit trail.
+type ImpersonationAuditor func(ctx context.Context, event ImpersonationEvent)
+
+// OnImpersonationBlockedHandler writes the response to a request rejected by
+// the middleware returned by NewImpersonationHTTPMiddleware.
+type OnImpersonationBlockedHandler func(w http.ResponseWriter, r *http.Request)
+
+type impersonationConfig struct {
+	blocked   []func(r *http.Request) bool
+	auditor   ImpersonationAuditor
+	onBlocked OnImpersonationBlockedHandler
+}
+
+// ImpersonationOption is a function type that can be provided to
+// NewImpersonationHTTPMiddleware to configure the impersonation policy.
+type ImpersonationOption func(c *impersonationConfig)
+
+// WithBlockedRoutes blocks impersonated requests to the given routes. Each
+// route is a path, optionally preceded by a method and a space, for example
+// "DELETE /accounts/". As with http.ServeMux, a path ending in a slash matches
+// all paths it prefixes, and other paths match exactly. Request paths are
+// cleaned before matching, so "/password/" and "//password" both match the
+// route "/password", and "/accounts" matches "/accounts/". A route without a
+// method matches all methods.
+func WithBlockedRoutes(routes ...string) ImpersonationOption {
+	return func(c *impersonationConfig) {
+		for _, route := range routes {
+			c.blocked = append(c.blocked, matchRoute(route))
+		}
+	}
+}
+
+// WithBlockedRequests blocks impersonated requests for which blocked returns
+// true.
+func WithBlockedRequests(blocked func(r *http.Request) bool) ImpersonationOption {
+	return func(c *impersonationConfig) {
+		c.blocked = append(c.blocked, blocked)
+	}
+}
+
+// WithImpersonationAuditor configures the auditor called when an
+// impersonated request performs a write, which is a request with a method
+// other than GET, HEAD, OPTIONS or TRACE. The auditor is called before the
+// request is handled, so it records attempted writes, including those that
+// go on to fail.
+func WithImpersonationAuditor(auditor ImpersonationAuditor) ImpersonationOption {
+	return func(c *impersonationConfig) {
+		c.auditor = auditor
+	}
+}
+
+// WithOnImpersonationBlocked configures the handler called when a request is
+// blocked. By default, a JSON:API style error response with a 403 status code
+// is written.
+func WithOnImpersonationBlocked(handler OnImpersonationBlockedHandler) ImpersonationOption {
+	return func(c *impersonationConfig) {
+		c.onBlocked = handler
+	}
+}
+
+// NewImpersonationHTTPMiddleware returns HTTP middleware that enforces the
+// impersonation policy configured by the options. Impersonated requests to
+// blocked routes are rejected, and impersonated writes are passed to the
+// auditor before they are handled. Requests that are not impersonated are
+// passed to the next handler unchanged.
+//
+// The middleware must run after the AuthenticatedUser has been added to the
+// request context.
+func NewImpersonationHTTPMiddleware(opts ...ImpersonationOption) func(http.Handler) http.Handler {
+	cfg := &impersonationConfig{
+		onBlocked: defaultImpersonationBlockedHandler,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !IsImpersonatedRequest(r.Context()) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			for _, blocked := range cfg.blocked {
+				if blocked(r) {
+					cfg.onBlocked(w, r)
+					return
+				}
+			}
+
+			if cfg.auditor != nil && isWriteMethod(r.Method) {
+				if event, ok := NewImpersonationEvent(r.Context(), r.Method+" "+r.URL.Path); ok {
+					cfg.auditor(r.Context(), event)
+				}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func matchRoute(route string) func(r *http.Request) bool {
+	method, pattern, ok := strings.Cut(route, " ")
+	if !ok {
+		method, pattern = "", route
+	}
+
+	return func(r *http.Request) bool {
+		if method != "" && method != r.Method {
+			return false
+		}
+
+		// Clean removes any trailing slash, so a prefix also matches the path
+		// without its trailing slash.
+		requestPath := path.Clean("/" + r.URL.Path)
+
+		if strings.HasSuffix(pattern, "/") {
+			return strings.HasPrefix(requestPath+"/", pattern)
+		}
+
+		return requestPath == pattern
+	}
+}
+
+func isWriteMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	default:
+		return true
+	}
+}
+
+// defaultImpersonationBlockedHandler writes a JSON:API style error response
+// with a 403 status code.
+func defaultImpersonationBlockedHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusForbidden)
+	_, _ = w.Write([]byte(`{"errors":[{"status":"403","title":"Forbidden","detail":"This action is not allowed while impersonating a user."}]}`))
+}
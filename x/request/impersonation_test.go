@@ -0,0 +1,160 @@
+package request_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newImpersonatedContext() context.Context {
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), newAuthenticatedUser())
+	return request.ContextWithRequestIDs(ctx, newRequestIDs())
+}
+
+func TestIsImpersonated(t *testing.T) {
+	assert.True(t, newAuthenticatedUser().IsImpersonated())
+	assert.False(t, request.AuthenticatedUser{UserID: "456"}.IsImpersonated())
+	assert.False(t, request.AuthenticatedUser{UserID: "456", RealUserID: "456"}.IsImpersonated())
+
+	assert.True(t, request.IsImpersonatedRequest(newImpersonatedContext()))
+	assert.False(t, request.IsImpersonatedRequest(context.Background()))
+}
+
+func TestDenyImpersonation(t *testing.T) {
+	assert.ErrorIs(t, request.DenyImpersonation(newImpersonatedContext()), request.ErrImpersonationNotAllowed)
+	assert.NoError(t, request.DenyImpersonation(context.Background()))
+}
+
+func TestNewImpersonationEvent(t *testing.T) {
+	event, ok := request.NewImpersonationEvent(newImpersonatedContext(), "delete survey")
+	require.True(t, ok)
+	assert.Equal(t, "delete survey", event.Operation)
+	assert.Equal(t, newAuthenticatedUser(), event.User)
+	assert.Equal(t, newRequestIDs(), event.RequestIDs)
+	assert.False(t, event.Time.IsZero())
+
+	_, ok = request.NewImpersonationEvent(context.Background(), "delete survey")
+	assert.False(t, ok)
+}
+
+func TestImpersonationHTTPMiddleware(t *testing.T) {
+	var events []request.ImpersonationEvent
+
+	mw := request.NewImpersonationHTTPMiddleware(
+		request.WithBlockedRoutes("/password", "DELETE /accounts/"),
+		request.WithBlockedRequests(func(r *http.Request) bool {
+			return r.URL.Query().Get("export") == "true"
+		}),
+		request.WithImpersonationAuditor(func(ctx context.Context, event request.ImpersonationEvent) {
+			events = append(events, event)
+		}))
+
+	serve := func(ctx context.Context, method, target string) (*httptest.ResponseRecorder, bool) {
+		handled := false
+		handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			handled = true
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(method, target, nil).WithContext(ctx))
+
+		return w, handled
+	}
+
+	cases := []struct {
+		name    string
+		method  string
+		target  string
+		blocked bool
+		audited bool
+	}{
+		{"exact route", http.MethodPost, "/password", true, false},
+		{"exact route with trailing slash", http.MethodPost, "/password/", true, false},
+		{"exact route with repeated slash", http.MethodPost, "//password", true, false},
+		{"exact route with dot segments", http.MethodPost, "/settings/../password", true, false},
+		{"exact route does not match sub-paths", http.MethodPost, "/password/reset", false, true},
+		{"prefix route with method", http.MethodDelete, "/accounts/123", true, false},
+		{"prefix route without trailing slash", http.MethodDelete, "/accounts", true, false},
+		{"prefix route with repeated slash", http.MethodDelete, "//accounts//123", true, false},
+		{"prefix route with other method", http.MethodGet, "/accounts/123", false, false},
+		{"prefix route does not match longer names", http.MethodDelete, "/accountsettings", false, true},
+		{"custom predicate", http.MethodGet, "/surveys?export=true", true, false},
+		{"audited write", http.MethodPut, "/surveys/1", false, true},
+		{"read", http.MethodGet, "/surveys/1", false, false},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			events = nil
+
+			w, handled := serve(newImpersonatedContext(), tc.method, tc.target)
+			assert.Equal(t, !tc.blocked, handled)
+
+			if tc.blocked {
+				assert.Equal(t, http.StatusForbidden, w.Code)
+			}
+
+			if tc.audited {
+				require.Len(t, events, 1)
+				assert.Equal(t, tc.method+" "+tc.target, events[0].Operation)
+				assert.Equal(t, newAuthenticatedUser(), events[0].User)
+				assert.Equal(t, newRequestIDs(), events[0].RequestIDs)
+			} else {
+				assert.Empty(t, events)
+			}
+		})
+	}
+
+	t.Run("ignores requests that are not impersonated", func(t *testing.T) {
+		events = nil
+
+		ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{UserID: "456"})
+		_, handled := serve(ctx, http.MethodPost, "/password")
+		assert.True(t, handled)
+		assert.Empty(t, events)
+	})
+
+	t.Run("uses the configured blocked handler", func(t *testing.T) {
+		handler := request.NewImpersonationHTTPMiddleware(
+			request.WithBlockedRoutes("/"),
+			request.WithOnImpersonationBlocked(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}))(http.NotFoundHandler())
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/anything", nil).WithContext(newImpersonatedContext()))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+	})
+}
+
+func ExampleNewImpersonationHTTPMiddleware() {
+	mw := request.NewImpersonationHTTPMiddleware(
+		request.WithBlockedRoutes("POST /password"),
+		request.WithImpersonationAuditor(func(ctx context.Context, event request.ImpersonationEvent) {
+			fmt.Println(event.User.RealUserID, "as", event.User.UserID, event.Operation)
+		}))
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	ctx := request.ContextWithAuthenticatedUser(context.Background(), request.AuthenticatedUser{
+		UserID:     "456",
+		RealUserID: "789",
+	})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/password", nil).WithContext(ctx))
+	fmt.Println(w.Code)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/surveys", nil).WithContext(ctx))
+
+	// Output:
+	// 403
+	// 789 as 456 POST /surveys
+}
@@ -0,0 +1,189 @@
+package request
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const requestMetadataKey = contextValueKey("requestMetadata")
+
+// Headers read by RequestMetadataFromHTTPRequest, in addition to the standard
+// Accept-Language, User-Agent and X-Forwarded-For headers.
+const (
+	TimezoneHeader          = "X-CA-Timezone"
+	ClientApplicationHeader = "X-CA-Client-Application"
+	ClientVersionHeader     = "X-CA-Client-Version"
+)
+
+// RequestMetadata holds descriptive attributes of a request and the client
+// that made it. Unlike RequestIDs and AuthenticatedUser, these attributes are
+// supplied by the client and are not verified, so they should be used for
+// reporting and targeting, never for authorization.
+type RequestMetadata struct {
+	// Locale is the BCP 47 language tag preferred by the client, for example
+	// "en-AU".
+	Locale string
+	// Timezone is the IANA time zone of the client, for example
+	// "Australia/Melbourne".
+	Timezone string
+	// ClientApplication is the name of the application making the request,
+	// for example "ios" or "performance-ui".
+	ClientApplication string
+	// ClientVersion is the version of the application making the request.
+	ClientVersion string
+	// SourceIP is the IP address the request originated from. Read from an
+	// HTTP request, it is only as reliable as the proxies trusted with
+	// WithTrustedProxies.
+	SourceIP string
+	// UserAgent is the User-Agent header sent by the client.
+	UserAgent string
+}
+
+// ContextWithRequestMetadata returns a new context with the given
+// RequestMetadata embedded as a value.
+func ContextWithRequestMetadata(ctx context.Context, metadata RequestMetadata) context.Context {
+	return context.WithValue(ctx, requestMetadataKey, metadata)
+}
+
+// RequestMetadataFromContext attempts to retrieve a RequestMetadata struct
+// from the given context, returning a RequestMetadata struct along with a
+// boolean signalling whether the retrieval was successful.
+func RequestMetadataFromContext(ctx context.Context) (RequestMetadata, bool) {
+	metadata, ok := ctx.Value(requestMetadataKey).(RequestMetadata)
+	return metadata, ok
+}
+
+// ContextHasRequestMetadata returns whether the given context contains a
+// RequestMetadata value.
+func ContextHasRequestMetadata(ctx context.Context) bool {
+	_, ok := RequestMetadataFromContext(ctx)
+	return ok
+}
+
+type metadataConfig struct {
+	trustedProxies int
+}
+
+// MetadataOption is a function type that can be provided to
+// RequestMetadataFromHTTPRequest and NewRequestMetadataHTTPMiddleware to
+// configure how RequestMetadata is read.
+type MetadataOption func(c *metadataConfig)
+
+// WithTrustedProxies sets the number of proxies in front of the service, such
+// as load balancers, that append the address they received the request from
+// to the X-Forwarded-For header. The source IP is the address appended by the
+// outermost trusted proxy, n addresses from the end of the header. The
+// addresses before it are supplied by the client and are not used. By default
+// no proxies are trusted, and the header is ignored.
+func WithTrustedProxies(n int) MetadataOption {
+	return func(c *metadataConfig) {
+		c.trustedProxies = n
+	}
+}
+
+func newMetadataConfig(opts []MetadataOption) *metadataConfig {
+	cfg := &metadataConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// RequestMetadataFromHTTPRequest reads RequestMetadata from the headers of an
+// HTTP request:
+//   - Locale is the most preferred language in the Accept-Language header.
+//   - Timezone, ClientApplication and ClientVersion are read from the
+//     TimezoneHeader, ClientApplicationHeader and ClientVersionHeader headers.
+//   - SourceIP is the address appended to the X-Forwarded-For header by the
+//     outermost proxy trusted with WithTrustedProxies, falling back to the
+//     remote address of the connection.
+//   - UserAgent is the User-Agent header.
+func RequestMetadataFromHTTPRequest(r *http.Request, opts ...MetadataOption) RequestMetadata {
+	cfg := newMetadataConfig(opts)
+
+	return RequestMetadata{
+		Locale:            preferredLanguage(r.Header.Get("Accept-Language")),
+		Timezone:          r.Header.Get(TimezoneHeader),
+		ClientApplication: r.Header.Get(ClientApplicationHeader),
+		ClientVersion:     r.Header.Get(ClientVersionHeader),
+		SourceIP:          cfg.sourceIP(r),
+		UserAgent:         r.UserAgent(),
+	}
+}
+
+// NewRequestMetadataHTTPMiddleware returns HTTP middleware that adds the
+// RequestMetadata read by RequestMetadataFromHTTPRequest to the request
+// context.
+func NewRequestMetadataHTTPMiddleware(opts ...MetadataOption) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := ContextWithRequestMetadata(r.Context(), RequestMetadataFromHTTPRequest(r, opts...))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// preferredLanguage returns the language range with the highest quality value
+// in an Accept-Language header, ignoring the "*" wildcard. Ranges with equal
+// quality values are preferred in the order they appear.
+func preferredLanguage(acceptLanguage string) string {
+	var (
+		preferred string
+		bestQ     float64
+	)
+
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+
+		q := 1.0
+		if params = strings.TrimSpace(params); strings.HasPrefix(params, "q=") {
+			parsed, err := strconv.ParseFloat(strings.TrimPrefix(params, "q="), 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		if q > bestQ {
+			preferred, bestQ = tag, q
+		}
+	}
+
+	return preferred
+}
+
+// sourceIP returns the address appended to the X-Forwarded-For header by the
+// outermost trusted proxy. If there are fewer addresses than trusted proxies,
+// or the address isn't an IP address, the remote address of the connection is
+// used instead.
+func (c *metadataConfig) sourceIP(r *http.Request) string {
+	if c.trustedProxies > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(header, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+
+		if len(hops) >= c.trustedProxies {
+			if ip := hops[len(hops)-c.trustedProxies]; net.ParseIP(ip) != nil {
+				return ip
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return host
+}
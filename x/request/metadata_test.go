@@ -0,0 +1,164 @@
+package request_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRequestMetadata() request.RequestMetadata {
+	return request.RequestMetadata{
+		Locale:            "en-AU",
+		Timezone:          "Australia/Melbourne",
+		ClientApplication: "performance-ui",
+		ClientVersion:     "1.2.3",
+		SourceIP:          "203.0.113.7",
+		UserAgent:         "Mozilla/5.0",
+	}
+}
+
+func TestContextWithRequestMetadata(t *testing.T) {
+	ctx := request.ContextWithRequestMetadata(context.Background(), newRequestMetadata())
+
+	metadata, ok := request.RequestMetadataFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, newRequestMetadata(), metadata)
+	assert.True(t, request.ContextHasRequestMetadata(ctx))
+
+	_, ok = request.RequestMetadataFromContext(context.Background())
+	assert.False(t, ok)
+	assert.False(t, request.ContextHasRequestMetadata(context.Background()))
+}
+
+func TestRequestMetadataFromHTTPRequest(t *testing.T) {
+	t.Run("reads all headers", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.Header.Set("Accept-Language", "fr;q=0.5, en-AU, en;q=0.8")
+		req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+		req.Header.Set("User-Agent", "Mozilla/5.0")
+		req.Header.Set(request.TimezoneHeader, "Australia/Melbourne")
+		req.Header.Set(request.ClientApplicationHeader, "performance-ui")
+		req.Header.Set(request.ClientVersionHeader, "1.2.3")
+
+		assert.Equal(t, newRequestMetadata(), request.RequestMetadataFromHTTPRequest(req, request.WithTrustedProxies(2)))
+	})
+
+	t.Run("falls back to the remote address", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		req.RemoteAddr = "198.51.100.1:54321"
+
+		metadata := request.RequestMetadataFromHTTPRequest(req)
+		assert.Equal(t, "198.51.100.1", metadata.SourceIP)
+	})
+
+	t.Run("reads the source IP appended by the outermost trusted proxy", func(t *testing.T) {
+		cases := []struct {
+			name      string
+			forwarded []string
+			trusted   int
+			want      string
+		}{
+			{name: "no trusted proxies", forwarded: []string{"203.0.113.7"}, trusted: 0, want: "192.0.2.1"},
+			{name: "one trusted proxy", forwarded: []string{"203.0.113.7"}, trusted: 1, want: "203.0.113.7"},
+			{name: "spoofed by the client", forwarded: []string{"10.1.1.1, 203.0.113.7"}, trusted: 1, want: "203.0.113.7"},
+			{name: "two trusted proxies", forwarded: []string{"10.1.1.1, 203.0.113.7, 10.0.0.1"}, trusted: 2, want: "203.0.113.7"},
+			{name: "several headers", forwarded: []string{"10.1.1.1", "203.0.113.7, 10.0.0.1"}, trusted: 2, want: "203.0.113.7"},
+			{name: "fewer hops than proxies", forwarded: []string{"203.0.113.7"}, trusted: 2, want: "192.0.2.1"},
+			{name: "not an IP address", forwarded: []string{"unknown"}, trusted: 1, want: "192.0.2.1"},
+			{name: "no header", forwarded: nil, trusted: 1, want: "192.0.2.1"},
+		}
+
+		for _, tc := range cases {
+			t.Run(tc.name, func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+				for _, value := range tc.forwarded {
+					req.Header.Add("X-Forwarded-For", value)
+				}
+
+				metadata := request.RequestMetadataFromHTTPRequest(req, request.WithTrustedProxies(tc.trusted))
+				assert.Equal(t, tc.want, metadata.SourceIP)
+			})
+		}
+	})
+
+	t.Run("parses Accept-Language preferences", func(t *testing.T) {
+		cases := map[string]string{
+			"":                         "",
+			"*":                        "",
+			"de":                       "de",
+			"*, ja;q=0.1":              "ja",
+			"en;q=0.2, pt-BR;q=0.9":    "pt-BR",
+			"es, fr":                   "es",
+			"zh;q=invalid, ko;q=0.3":   "ko",
+			"en;q=0, nl-BE ; q=0.7":    "nl-BE",
+			"  it-IT  ;q=1.0 , it;q=1": "it-IT",
+		}
+
+		for header, want := range cases {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			req.Header.Set("Accept-Language", header)
+
+			assert.Equal(t, want, request.RequestMetadataFromHTTPRequest(req).Locale, header)
+		}
+	})
+}
+
+func TestRequestMetadataHTTPMiddleware(t *testing.T) {
+	var (
+		metadata request.RequestMetadata
+		ok       bool
+	)
+
+	handler := request.NewRequestMetadataHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		metadata, ok = request.RequestMetadataFromContext(r.Context())
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set(request.ClientApplicationHeader, "ios")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.True(t, ok)
+	assert.Equal(t, "ios", metadata.ClientApplication)
+	assert.Equal(t, "192.0.2.1", metadata.SourceIP)
+}
+
+func ExampleRequestMetadataFromHTTPRequest() {
+	req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
+	req.Header.Set("Accept-Language", "en;q=0.8, en-AU")
+	req.Header.Set(request.TimezoneHeader, "Australia/Melbourne")
+
+	ctx := request.ContextWithRequestMetadata(req.Context(), request.RequestMetadataFromHTTPRequest(req))
+
+	if metadata, ok := request.RequestMetadataFromContext(ctx); ok {
+		fmt.Println(metadata.Locale)
+		fmt.Println(metadata.Timezone)
+	}
+
+	// Output:
+	// en-AU
+	// Australia/Melbourne
+}
+
+func ExampleWithTrustedProxies() {
+	req := httptest.NewRequest(http.MethodGet, "/surveys", nil)
+	req.RemoteAddr = "10.0.0.1:4321"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1, 203.0.113.7")
+
+	// The load balancer appends the address it received the request from, so
+	// only the last hop can be trusted.
+	metadata := request.RequestMetadataFromHTTPRequest(req, request.WithTrustedProxies(1))
+	fmt.Println(metadata.SourceIP)
+
+	// Without trusted proxies, X-Forwarded-For is ignored.
+	metadata = request.RequestMetadataFromHTTPRequest(req)
+	fmt.Println(metadata.SourceIP)
+
+	// Output:
+	// 203.0.113.7
+	// 10.0.0.1
+}
@@ -0,0 +1,142 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Keys under which request-scoped attributes are written to a Carrier.
+const (
+	RequestIDKey     = "x-request-id"
+	CorrelationIDKey = "x-correlation-id"
+	AccountIDKey     = "x-ca-account-id"
+	UserIDKey        = "x-ca-user-id"
+	RealUserIDKey    = "x-ca-real-user-id"
+)
+
+// Carrier is the storage medium used to propagate request-scoped attributes
+// across process boundaries, such as HTTP headers or message attributes. It
+// mirrors OpenTelemetry's TextMapCarrier, so that implementations can be
+// shared.
+type Carrier interface {
+	// Get returns the value stored under the key, or an empty string if there
+	// is none.
+	Get(key string) string
+
+	// Set stores a value under the key, replacing any existing value.
+	Set(key string, value string)
+
+	// Keys lists the keys stored in the carrier.
+	Keys() []string
+}
+
+// Inject writes the RequestIDs and AuthenticatedUser in the context, if any,
+// to the carrier. Empty identifiers are not written.
+func Inject(ctx context.Context, carrier Carrier) {
+	if ids, ok := RequestIDsFromContext(ctx); ok {
+		setIfNotEmpty(carrier, RequestIDKey, ids.RequestID)
+		setIfNotEmpty(carrier, CorrelationIDKey, ids.CorrelationID)
+	}
+
+	if user, ok := AuthenticatedUserFromContext(ctx); ok {
+		setIfNotEmpty(carrier, AccountIDKey, user.CustomerAccountID)
+		setIfNotEmpty(carrier, UserIDKey, user.UserID)
+		setIfNotEmpty(carrier, RealUserIDKey, user.RealUserID)
+	}
+}
+
+// Extract returns a copy of the context with the RequestIDs and
+// AuthenticatedUser read from the carrier. RequestIDs are added if either
+// identifier is present, and an AuthenticatedUser is added if a user ID is
+// present. Values already in the context are replaced.
+//
+// The carrier is trusted: only extract from carriers written by other
+// services, such as messages on an internal queue, and never from requests
+// made by end users. Supply WithValidation to check the identifiers before
+// they are added to the context.
+func Extract(ctx context.Context, carrier Carrier, opts ...ExtractOption) context.Context {
+	cfg := newExtractConfig(opts)
+
+	ids := RequestIDs{
+		RequestID:     carrier.Get(RequestIDKey),
+		CorrelationID: carrier.Get(CorrelationIDKey),
+	}
+	if ids, ok := cfg.checkRequestIDs(ctx, ids); ok {
+		ctx = ContextWithRequestIDs(ctx, ids)
+	}
+
+	user := AuthenticatedUser{
+		CustomerAccountID: carrier.Get(AccountIDKey),
+		UserID:            carrier.Get(UserIDKey),
+		RealUserID:        carrier.Get(RealUserIDKey),
+	}
+	if cfg.checkUser(ctx, user) {
+		ctx = ContextWithAuthenticatedUser(ctx, user)
+	}
+
+	return ctx
+}
+
+func setIfNotEmpty(carrier Carrier, key, value string) {
+	if value != "" {
+		carrier.Set(key, value)
+	}
+}
+
+// MapCarrier is a Carrier backed by a map, for example the metadata of an
+// EventBridge event detail.
+type MapCarrier map[string]string
+
+// Get implements Carrier.
+func (c MapCarrier) Get(key string) string {
+	return c[key]
+}
+
+// Set implements Carrier.
+func (c MapCarrier) Set(key string, value string) {
+	c[key] = value
+}
+
+// Keys implements Carrier.
+func (c MapCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// HeaderCarrier is a Carrier backed by HTTP headers. Keys are
+// case-insensitive.
+type HeaderCarrier http.Header
+
+// Get implements Carrier.
+func (c HeaderCarrier) Get(key string) string {
+	return http.Header(c).Get(key)
+}
+
+// Set implements Carrier.
+func (c HeaderCarrier) Set(key string, value string) {
+	http.Header(c).Set(key, value)
+}
+
+// Keys implements Carrier. Keys are returned in lower case.
+func (c HeaderCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, strings.ToLower(key))
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
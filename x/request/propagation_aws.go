@@ -0,0 +1,122 @@
+package request
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+)
+
+const stringDataType = "String"
+
+// SQSMessageAttributeCarrier is a Carrier backed by the message attributes of
+// an SQS message sent or received with the AWS SDK. Only String attributes
+// are read.
+type SQSMessageAttributeCarrier map[string]*sqs.MessageAttributeValue
+
+// Get implements Carrier.
+func (c SQSMessageAttributeCarrier) Get(key string) string {
+	if attr, ok := c[key]; ok && attr != nil {
+		return aws.StringValue(attr.StringValue)
+	}
+
+	return ""
+}
+
+// Set implements Carrier.
+func (c SQSMessageAttributeCarrier) Set(key string, value string) {
+	c[key] = &sqs.MessageAttributeValue{
+		DataType:    aws.String(stringDataType),
+		StringValue: aws.String(value),
+	}
+}
+
+// Keys implements Carrier.
+func (c SQSMessageAttributeCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// SNSMessageAttributeCarrier is a Carrier backed by the message attributes of
+// an SNS message published with the AWS SDK. The attributes are delivered to
+// SQS subscribers as SQS message attributes.
+type SNSMessageAttributeCarrier map[string]*sns.MessageAttributeValue
+
+// Get implements Carrier.
+func (c SNSMessageAttributeCarrier) Get(key string) string {
+	if attr, ok := c[key]; ok && attr != nil {
+		return aws.StringValue(attr.StringValue)
+	}
+
+	return ""
+}
+
+// Set implements Carrier.
+func (c SNSMessageAttributeCarrier) Set(key string, value string) {
+	c[key] = &sns.MessageAttributeValue{
+		DataType:    aws.String(stringDataType),
+		StringValue: aws.String(value),
+	}
+}
+
+// Keys implements Carrier.
+func (c SNSMessageAttributeCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// SQSEventMessageAttributeCarrier is a Carrier backed by the message
+// attributes of an SQS message delivered to a Lambda function:
+//   ctx = request.Extract(ctx, request.SQSEventMessageAttributeCarrier(msg.MessageAttributes))
+type SQSEventMessageAttributeCarrier map[string]events.SQSMessageAttribute
+
+// Get implements Carrier.
+func (c SQSEventMessageAttributeCarrier) Get(key string) string {
+	if attr, ok := c[key]; ok {
+		return aws.StringValue(attr.StringValue)
+	}
+
+	return ""
+}
+
+// Set implements Carrier.
+func (c SQSEventMessageAttributeCarrier) Set(key string, value string) {
+	c[key] = events.SQSMessageAttribute{
+		DataType:    stringDataType,
+		StringValue: aws.String(value),
+	}
+}
+
+// Keys implements Carrier.
+func (c SQSEventMessageAttributeCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// SNSEventMessageAttributeCarrier is a Carrier backed by the message
+// attributes of an SNS message delivered to a Lambda function, which are
+// decoded as {"Type": ..., "Value": ...} objects:
+//   ctx = request.Extract(ctx, request.SNSEventMessageAttributeCarrier(record.SNS.MessageAttributes))
+type SNSEventMessageAttributeCarrier map[string]interface{}
+
+// Get implements Carrier.
+func (c SNSEventMessageAttributeCarrier) Get(key string) string {
+	attr, ok := c[key].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	value, _ := attr["Value"].(string)
+
+	return value
+}
+
+// Set implements Carrier.
+func (c SNSEventMessageAttributeCarrier) Set(key string, value string) {
+	c[key] = map[string]interface{}{
+		"Type":  stringDataType,
+		"Value": value,
+	}
+}
+
+// Keys implements Carrier.
+func (c SNSEventMessageAttributeCarrier) Keys() []string {
+	return sortedKeys(c)
+}
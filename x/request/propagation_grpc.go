@@ -0,0 +1,108 @@
+package request
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// MetadataCarrier is a Carrier backed by gRPC metadata. Keys are
+// case-insensitive, and only the first value of a key is read.
+type MetadataCarrier metadata.MD
+
+// Get implements Carrier.
+func (c MetadataCarrier) Get(key string) string {
+	if values := metadata.MD(c).Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Set implements Carrier.
+func (c MetadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys implements Carrier.
+func (c MetadataCarrier) Keys() []string {
+	return sortedKeys(c)
+}
+
+// ContextWithOutgoingMetadata returns a copy of the context whose outgoing
+// gRPC metadata includes the RequestIDs and AuthenticatedUser in the context,
+// as written by Inject. Other outgoing metadata is kept.
+func ContextWithOutgoingMetadata(ctx context.Context) context.Context {
+	if !ContextHasRequestIDs(ctx) && !ContextHasAuthenticatedUser(ctx) {
+		return ctx
+	}
+
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	Inject(ctx, MetadataCarrier(md))
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+// ContextFromIncomingMetadata returns a copy of the context with the
+// RequestIDs and AuthenticatedUser read by Extract from the incoming gRPC
+// metadata.
+func ContextFromIncomingMetadata(ctx context.Context, opts ...ExtractOption) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	return Extract(ctx, MetadataCarrier(md), opts...)
+}
+
+// NewGRPCUnaryServerInterceptor returns a gRPC interceptor that adds the
+// RequestIDs and AuthenticatedUser in the incoming metadata to the context of
+// unary calls. As with Extract, the metadata is trusted, so only use it for
+// services called by other internal services.
+func NewGRPCUnaryServerInterceptor(opts ...ExtractOption) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		return handler(ContextFromIncomingMetadata(ctx, opts...), req)
+	}
+}
+
+// NewGRPCStreamServerInterceptor returns a gRPC interceptor that adds the
+// RequestIDs and AuthenticatedUser in the incoming metadata to the context of
+// streaming calls.
+func NewGRPCStreamServerInterceptor(opts ...ExtractOption) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          ContextFromIncomingMetadata(ss.Context(), opts...),
+		})
+	}
+}
+
+// NewGRPCUnaryClientInterceptor returns a gRPC interceptor that propagates
+// the RequestIDs and AuthenticatedUser in the context to the metadata of
+// outgoing unary calls.
+func NewGRPCUnaryClientInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(ContextWithOutgoingMetadata(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// NewGRPCStreamClientInterceptor returns a gRPC interceptor that propagates
+// the RequestIDs and AuthenticatedUser in the context to the metadata of
+// outgoing streaming calls.
+func NewGRPCStreamClientInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(ContextWithOutgoingMetadata(ctx), desc, cc, method, opts...)
+	}
+}
+
+// serverStream is a grpc.ServerStream with a replaced context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,159 @@
+package request_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// contextRecordingHealthServer records the context of each call it handles.
+type contextRecordingHealthServer struct {
+	healthpb.UnimplementedHealthServer
+	contexts chan context.Context
+}
+
+func (s *contextRecordingHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	s.contexts <- ctx
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *contextRecordingHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	s.contexts <- stream.Context()
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func newGRPCTestClient(t *testing.T) (healthpb.HealthClient, chan context.Context) {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(request.NewGRPCUnaryServerInterceptor()),
+		grpc.StreamInterceptor(request.NewGRPCStreamServerInterceptor()))
+
+	health := &contextRecordingHealthServer{contexts: make(chan context.Context, 1)}
+	healthpb.RegisterHealthServer(server, health)
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(request.NewGRPCUnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(request.NewGRPCStreamClientInterceptor()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn), health.contexts
+}
+
+func newGRPCCallContext() context.Context {
+	ctx := request.ContextWithRequestIDs(context.Background(), newRequestIDs())
+	return request.ContextWithAuthenticatedUser(ctx, newAuthenticatedUser())
+}
+
+func assertRequestContext(t *testing.T, ctx context.Context) {
+	t.Helper()
+
+	ids, ok := request.RequestIDsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, newRequestIDs(), ids)
+
+	user, ok := request.AuthenticatedUserFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, newAuthenticatedUser(), user)
+}
+
+func TestGRPCInterceptors(t *testing.T) {
+	client, contexts := newGRPCTestClient(t)
+
+	t.Run("propagates unary calls", func(t *testing.T) {
+		_, err := client.Check(newGRPCCallContext(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		assertRequestContext(t, <-contexts)
+	})
+
+	t.Run("propagates streaming calls", func(t *testing.T) {
+		stream, err := client.Watch(newGRPCCallContext(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		_, err = stream.Recv()
+		require.NoError(t, err)
+
+		assertRequestContext(t, <-contexts)
+	})
+
+	t.Run("keeps other outgoing metadata", func(t *testing.T) {
+		ctx := metadata.AppendToOutgoingContext(newGRPCCallContext(), "x-custom", "value")
+
+		_, err := client.Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		serverCtx := <-contexts
+		assertRequestContext(t, serverCtx)
+
+		md, _ := metadata.FromIncomingContext(serverCtx)
+		assert.Equal(t, []string{"value"}, md.Get("x-custom"))
+	})
+
+	t.Run("adds nothing without request attributes", func(t *testing.T) {
+		_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+
+		serverCtx := <-contexts
+		assert.False(t, request.ContextHasRequestIDs(serverCtx))
+		assert.False(t, request.ContextHasAuthenticatedUser(serverCtx))
+	})
+}
+
+func TestContextWithOutgoingMetadata(t *testing.T) {
+	ctx := metadata.AppendToOutgoingContext(context.Background(), request.RequestIDKey, "stale")
+	ctx = request.ContextWithRequestIDs(ctx, newRequestIDs())
+
+	ctx = request.ContextWithOutgoingMetadata(ctx)
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	require.True(t, ok)
+	assert.Equal(t, []string{"123"}, md.Get(request.RequestIDKey))
+	assert.Equal(t, []string{"456"}, md.Get(request.CorrelationIDKey))
+}
+
+func TestMetadataCarrier(t *testing.T) {
+	carrier := request.MetadataCarrier(metadata.Pairs("X-Request-Id", "123"))
+
+	assert.Equal(t, "123", carrier.Get(request.RequestIDKey))
+	assert.Equal(t, []string{request.RequestIDKey}, carrier.Keys())
+
+	carrier.Set("X-Correlation-Id", "456")
+	assert.Equal(t, "456", carrier.Get(request.CorrelationIDKey))
+}
+
+func ExampleNewGRPCUnaryServerInterceptor() {
+	// Servers add the caller's request IDs and user to the context of each
+	// call.
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(request.NewGRPCUnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(request.NewGRPCStreamServerInterceptor()))
+	defer server.Stop()
+
+	// Clients pass them on to the services they call.
+	conn, err := grpc.Dial("localhost:50051",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(request.NewGRPCUnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(request.NewGRPCStreamClientInterceptor()))
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+}
@@ -0,0 +1,111 @@
+package request_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInjectExtract(t *testing.T) {
+	ctx := request.ContextWithRequestIDs(context.Background(), newRequestIDs())
+	ctx = request.ContextWithAuthenticatedUser(ctx, newAuthenticatedUser())
+
+	carriers := map[string]request.Carrier{
+		"map":                    request.MapCarrier{},
+		"http header":            request.HeaderCarrier(http.Header{}),
+		"sqs message attributes": request.SQSMessageAttributeCarrier(map[string]*sqs.MessageAttributeValue{}),
+		"sns message attributes": request.SNSMessageAttributeCarrier(map[string]*sns.MessageAttributeValue{}),
+		"sqs event attributes":   request.SQSEventMessageAttributeCarrier(map[string]events.SQSMessageAttribute{}),
+		"sns event attributes":   request.SNSEventMessageAttributeCarrier(map[string]interface{}{}),
+	}
+
+	for name, carrier := range carriers {
+		carrier := carrier
+		t.Run(name, func(t *testing.T) {
+			request.Inject(ctx, carrier)
+
+			assert.Equal(t, []string{
+				request.AccountIDKey,
+				request.RealUserIDKey,
+				request.UserIDKey,
+				request.CorrelationIDKey,
+				request.RequestIDKey,
+			}, carrier.Keys())
+
+			extracted := request.Extract(context.Background(), carrier)
+
+			ids, ok := request.RequestIDsFromContext(extracted)
+			assert.True(t, ok)
+			assert.Equal(t, newRequestIDs(), ids)
+
+			user, ok := request.AuthenticatedUserFromContext(extracted)
+			assert.True(t, ok)
+			assert.Equal(t, newAuthenticatedUser(), user)
+		})
+	}
+}
+
+func TestInjectEmptyContext(t *testing.T) {
+	carrier := request.MapCarrier{}
+	request.Inject(context.Background(), carrier)
+	assert.Empty(t, carrier)
+
+	ctx := request.Extract(context.Background(), carrier)
+	assert.False(t, request.ContextHasRequestIDs(ctx))
+	assert.False(t, request.ContextHasAuthenticatedUser(ctx))
+}
+
+func TestExtractPartial(t *testing.T) {
+	ctx := request.Extract(context.Background(), request.MapCarrier{
+		request.CorrelationIDKey: "456",
+		request.AccountIDKey:     "123",
+	})
+
+	ids, ok := request.RequestIDsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, request.RequestIDs{CorrelationID: "456"}, ids)
+
+	// An account without a user is not an authenticated user.
+	assert.False(t, request.ContextHasAuthenticatedUser(ctx))
+}
+
+func TestHeaderCarrierIsCaseInsensitive(t *testing.T) {
+	header := http.Header{}
+	header.Set("X-Request-Id", "123")
+
+	ctx := request.Extract(context.Background(), request.HeaderCarrier(header))
+
+	ids, ok := request.RequestIDsFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "123", ids.RequestID)
+}
+
+func ExampleInject() {
+	ctx := request.ContextWithRequestIDs(context.Background(), request.RequestIDs{
+		RequestID:     "123",
+		CorrelationID: "456",
+	})
+
+	// On the producer side, write the request IDs to the message attributes.
+	attrs := map[string]*sqs.MessageAttributeValue{}
+	request.Inject(ctx, request.SQSMessageAttributeCarrier(attrs))
+
+	// On the consumer side, restore them to the context.
+	ctx = request.Extract(context.Background(), request.SQSMessageAttributeCarrier(attrs))
+
+	if ids, ok := request.RequestIDsFromContext(ctx); ok {
+		fmt.Println(ids.RequestID)
+		fmt.Println(ids.CorrelationID)
+	}
+
+	// Output:
+	// 123
+	// 456
+}
@@ -0,0 +1,38 @@
+package request
+
+import "net/http"
+
+// NewRequestIDHTTPMiddleware returns HTTP middleware that adds RequestIDs to
+// the request context. The request and correlation IDs are read from the
+// X-Request-Id and X-Correlation-Id headers. The headers are sent by the
+// client, so they are validated with the default rules, and invalid IDs are
+// discarded; the rules can be changed with WithValidation, or validation
+// turned off with WithoutValidation. A missing request ID is generated with
+// NewID, and a missing correlation ID is set to the request ID, so that the
+// calls made while handling the first request in a chain can be correlated
+// with it. The request ID is also returned in the X-Request-Id response
+// header.
+func NewRequestIDHTTPMiddleware(opts ...ExtractOption) func(http.Handler) http.Handler {
+	cfg := newExtractConfig(append([]ExtractOption{WithValidation()}, opts...))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids, _ := cfg.checkRequestIDs(r.Context(), RequestIDs{
+				RequestID:     r.Header.Get(RequestIDKey),
+				CorrelationID: r.Header.Get(CorrelationIDKey),
+			})
+
+			if ids.RequestID == "" {
+				ids.RequestID = NewID()
+			}
+
+			if ids.CorrelationID == "" {
+				ids.CorrelationID = ids.RequestID
+			}
+
+			w.Header().Set(RequestIDKey, ids.RequestID)
+
+			next.ServeHTTP(w, r.WithContext(ContextWithRequestIDs(r.Context(), ids)))
+		})
+	}
+}
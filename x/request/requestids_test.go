@@ -3,6 +3,9 @@ package request_test
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/cultureamp/ca-go/x/request"
@@ -80,3 +83,104 @@ func TestContextHasRequestIDsFails(t *testing.T) {
 	ok := request.ContextHasRequestIDs(ctx)
 	assert.False(t, ok)
 }
+
+func TestRequestIDHTTPMiddleware(t *testing.T) {
+	serve := func(req *http.Request, opts ...request.ExtractOption) (request.RequestIDs, *httptest.ResponseRecorder) {
+		var ids request.RequestIDs
+
+		handler := request.NewRequestIDHTTPMiddleware(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ids, _ = request.RequestIDsFromContext(r.Context())
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return ids, w
+	}
+
+	t.Run("generates request IDs", func(t *testing.T) {
+		t.Cleanup(func() { request.SetIDGenerator(nil) })
+		request.SetIDGenerator(request.IDGeneratorFunc(func() string { return "generated" }))
+
+		ids, w := serve(httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, request.RequestIDs{RequestID: "generated", CorrelationID: "generated"}, ids)
+		assert.Equal(t, "generated", w.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("keeps incoming request IDs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "123")
+		req.Header.Set("X-Correlation-Id", "456")
+
+		ids, w := serve(req)
+		assert.Equal(t, newRequestIDs(), ids)
+		assert.Equal(t, "123", w.Header().Get("X-Request-Id"))
+	})
+
+	t.Run("generates a request ID for an incoming correlation ID", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Correlation-Id", "456")
+
+		ids, _ := serve(req)
+		assert.Equal(t, "456", ids.CorrelationID)
+		assert.NotEmpty(t, ids.RequestID)
+		assert.NotEqual(t, "456", ids.RequestID)
+	})
+
+	t.Run("replaces invalid incoming request IDs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "<script>")
+		req.Header.Set("X-Correlation-Id", "456")
+
+		ids, _ := serve(req)
+		assert.Equal(t, "456", ids.CorrelationID)
+		assert.NotEqual(t, "<script>", ids.RequestID)
+		assert.NotEmpty(t, ids.RequestID)
+	})
+
+	t.Run("replaces overlong incoming correlation IDs", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "123")
+		req.Header.Set("X-Correlation-Id", strings.Repeat("a", 129))
+
+		ids, _ := serve(req)
+		assert.Equal(t, request.RequestIDs{RequestID: "123", CorrelationID: "123"}, ids)
+	})
+
+	t.Run("validates with the configured rules", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "123")
+
+		ids, _ := serve(req, request.WithValidation(request.WithUUIDFormat()))
+		assert.NotEqual(t, "123", ids.RequestID)
+	})
+
+	t.Run("keeps invalid incoming request IDs without validation", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-Request-Id", "<script>")
+
+		ids, _ := serve(req, request.WithoutValidation())
+		assert.Equal(t, "<script>", ids.RequestID)
+	})
+}
+
+func ExampleNewRequestIDHTTPMiddleware() {
+	handler := request.NewRequestIDHTTPMiddleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids, _ := request.RequestIDsFromContext(r.Context())
+		fmt.Println(ids.RequestID)
+		fmt.Println(ids.CorrelationID)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/surveys", nil)
+	req.Header.Set("X-Request-Id", "abc-123")
+	req.Header.Set("X-Correlation-Id", "not a valid ID")
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	fmt.Println(w.Header().Get("X-Request-Id"))
+
+	// Output:
+	// abc-123
+	// abc-123
+	// abc-123
+}
@@ -0,0 +1,50 @@
+package request
+
+import "context"
+
+const authenticatedServiceKey = contextValueKey("authenticatedService")
+
+// AuthenticatedService holds the identity of another service making an
+// authenticated request, as opposed to an AuthenticatedUser, which represents
+// a human. A request may carry both, when a service calls on behalf of a user.
+type AuthenticatedService struct {
+	// Name is the name of the calling service, for example "murmur".
+	Name string
+	// ClientID is the identifier of the credentials the service authenticated
+	// with, such as an OAuth client ID or a certificate's URI SAN.
+	ClientID string
+	// Scopes are the permissions granted to the calling service.
+	Scopes []string
+}
+
+// HasScope returns whether the service was granted the given scope.
+func (s AuthenticatedService) HasScope(scope string) bool {
+	for _, granted := range s.Scopes {
+		if granted == scope {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ContextWithAuthenticatedService returns a new context with the given
+// service embedded as a value.
+func ContextWithAuthenticatedService(ctx context.Context, service AuthenticatedService) context.Context {
+	return context.WithValue(ctx, authenticatedServiceKey, service)
+}
+
+// AuthenticatedServiceFromContext attempts to retrieve an AuthenticatedService
+// from the given context, returning an AuthenticatedService along with a
+// boolean signalling whether the retrieval was successful.
+func AuthenticatedServiceFromContext(ctx context.Context) (AuthenticatedService, bool) {
+	service, ok := ctx.Value(authenticatedServiceKey).(AuthenticatedService)
+	return service, ok
+}
+
+// ContextHasAuthenticatedService returns whether the given context contains
+// an AuthenticatedService value.
+func ContextHasAuthenticatedService(ctx context.Context) bool {
+	_, ok := AuthenticatedServiceFromContext(ctx)
+	return ok
+}
@@ -0,0 +1,168 @@
+package request
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ServiceTokenHeader is the HTTP header a calling service sends its token in.
+// A dedicated header is used so that service tokens are not confused with the
+// credentials of a user in the Authorization header.
+const ServiceTokenHeader = "X-CA-Service-Token"
+
+// ErrUnauthenticatedService is returned when a request does not carry valid
+// service credentials.
+var ErrUnauthenticatedService = errors.New("unauthenticated service")
+
+// ServiceTokenVerifier verifies the token presented by a calling service and
+// returns its identity. An error is returned if the token is invalid.
+type ServiceTokenVerifier func(ctx context.Context, token string) (AuthenticatedService, error)
+
+// ServiceCertificateMapper returns the identity of the service that presented
+// a verified TLS client certificate. An error is returned if the certificate
+// doesn't identify a known service.
+type ServiceCertificateMapper func(cert *x509.Certificate) (AuthenticatedService, error)
+
+// OnUnauthenticatedServiceHandler writes the response to a request rejected
+// by the middleware returned by NewAuthenticatedServiceHTTPMiddleware.
+type OnUnauthenticatedServiceHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+type serviceAuthenticationConfig struct {
+	certificateMapper ServiceCertificateMapper
+	tokenVerifier     ServiceTokenVerifier
+	required          bool
+	onUnauthenticated OnUnauthenticatedServiceHandler
+}
+
+// ServiceAuthenticationOption is a function type that can be provided to
+// NewAuthenticatedServiceHTTPMiddleware to configure how calling services are
+// authenticated.
+type ServiceAuthenticationOption func(c *serviceAuthenticationConfig)
+
+// WithClientCertificates authenticates services by the TLS client certificate
+// verified by the server, using mapper to identify the service. If mapper is
+// nil, ServiceFromCertificate is used.
+func WithClientCertificates(mapper ServiceCertificateMapper) ServiceAuthenticationOption {
+	return func(c *serviceAuthenticationConfig) {
+		if mapper == nil {
+			mapper = ServiceFromCertificate
+		}
+		c.certificateMapper = mapper
+	}
+}
+
+// WithServiceTokens authenticates services by the token in the
+// ServiceTokenHeader header, using verifier to validate it.
+func WithServiceTokens(verifier ServiceTokenVerifier) ServiceAuthenticationOption {
+	return func(c *serviceAuthenticationConfig) {
+		c.tokenVerifier = verifier
+	}
+}
+
+// WithServiceAuthenticationRequired rejects requests that don't present
+// service credentials. By default, such requests are passed to the next
+// handler without an AuthenticatedService, so that one handler can serve both
+// users and services.
+func WithServiceAuthenticationRequired() ServiceAuthenticationOption {
+	return func(c *serviceAuthenticationConfig) {
+		c.required = true
+	}
+}
+
+// WithOnUnauthenticatedService configures the handler called when a request is
+// rejected. By default, a JSON:API style error response with a 401 status code
+// is written.
+func WithOnUnauthenticatedService(handler OnUnauthenticatedServiceHandler) ServiceAuthenticationOption {
+	return func(c *serviceAuthenticationConfig) {
+		c.onUnauthenticated = handler
+	}
+}
+
+// NewAuthenticatedServiceHTTPMiddleware returns HTTP middleware that adds the
+// AuthenticatedService identified by the request's credentials to the request
+// context. A verified TLS client certificate is used in preference to a
+// service token. Requests with invalid credentials are always rejected, and
+// requests without credentials are rejected if
+// WithServiceAuthenticationRequired is supplied.
+func NewAuthenticatedServiceHTTPMiddleware(opts ...ServiceAuthenticationOption) func(http.Handler) http.Handler {
+	cfg := &serviceAuthenticationConfig{
+		onUnauthenticated: defaultUnauthenticatedServiceHandler,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			service, ok, err := cfg.authenticate(r)
+			if err == nil && !ok && cfg.required {
+				err = fmt.Errorf("%w: no service credentials", ErrUnauthenticatedService)
+			}
+
+			if err != nil {
+				cfg.onUnauthenticated(w, r, err)
+				return
+			}
+
+			if ok {
+				r = r.WithContext(ContextWithAuthenticatedService(r.Context(), service))
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *serviceAuthenticationConfig) authenticate(r *http.Request) (AuthenticatedService, bool, error) {
+	if c.certificateMapper != nil && r.TLS != nil && len(r.TLS.VerifiedChains) > 0 {
+		service, err := c.certificateMapper(r.TLS.VerifiedChains[0][0])
+		if err != nil {
+			return AuthenticatedService{}, false, fmt.Errorf("%w: client certificate: %s", ErrUnauthenticatedService, err)
+		}
+
+		return service, true, nil
+	}
+
+	if token := r.Header.Get(ServiceTokenHeader); c.tokenVerifier != nil && token != "" {
+		service, err := c.tokenVerifier(r.Context(), token)
+		if err != nil {
+			return AuthenticatedService{}, false, fmt.Errorf("%w: service token: %s", ErrUnauthenticatedService, err)
+		}
+
+		return service, true, nil
+	}
+
+	return AuthenticatedService{}, false, nil
+}
+
+// ServiceFromCertificate identifies a service by its client certificate. The
+// subject common name is the service name, the first URI SAN (such as a SPIFFE
+// ID) is the client ID, and the subject organisational units are the scopes.
+// An error is returned if the certificate has no common name.
+func ServiceFromCertificate(cert *x509.Certificate) (AuthenticatedService, error) {
+	if cert.Subject.CommonName == "" {
+		return AuthenticatedService{}, errors.New("certificate has no common name")
+	}
+
+	service := AuthenticatedService{
+		Name:   cert.Subject.CommonName,
+		Scopes: cert.Subject.OrganizationalUnit,
+	}
+
+	if len(cert.URIs) > 0 {
+		service.ClientID = cert.URIs[0].String()
+	}
+
+	return service, nil
+}
+
+// defaultUnauthenticatedServiceHandler writes a JSON:API style error response
+// with a 401 status code.
+func defaultUnauthenticatedServiceHandler(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnauthorized)
+	_, _ = w.Write([]byte(`{"errors":[{"status":"401","title":"Unauthorized"}]}`))
+}
@@ -0,0 +1,198 @@
+package request_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthenticatedService() request.AuthenticatedService {
+	return request.AuthenticatedService{
+		Name:     "murmur",
+		ClientID: "spiffe://cultureamp.net/murmur",
+		Scopes:   []string{"surveys:read", "surveys:write"},
+	}
+}
+
+func newClientCertificate(t *testing.T) *x509.Certificate {
+	t.Helper()
+
+	clientID, err := url.Parse("spiffe://cultureamp.net/murmur")
+	require.NoError(t, err)
+
+	return &x509.Certificate{
+		Subject: pkix.Name{
+			CommonName:         "murmur",
+			OrganizationalUnit: []string{"surveys:read", "surveys:write"},
+		},
+		URIs: []*url.URL{clientID},
+	}
+}
+
+func verifyTestToken(ctx context.Context, token string) (request.AuthenticatedService, error) {
+	if token != "valid-token" {
+		return request.AuthenticatedService{}, errors.New("invalid token")
+	}
+
+	return newAuthenticatedService(), nil
+}
+
+func TestContextWithAuthenticatedService(t *testing.T) {
+	ctx := request.ContextWithAuthenticatedService(context.Background(), newAuthenticatedService())
+
+	service, ok := request.AuthenticatedServiceFromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, newAuthenticatedService(), service)
+	assert.True(t, request.ContextHasAuthenticatedService(ctx))
+
+	_, ok = request.AuthenticatedServiceFromContext(context.Background())
+	assert.False(t, ok)
+	assert.False(t, request.ContextHasAuthenticatedService(context.Background()))
+}
+
+func TestAuthenticatedServiceHasScope(t *testing.T) {
+	service := newAuthenticatedService()
+	assert.True(t, service.HasScope("surveys:read"))
+	assert.False(t, service.HasScope("surveys:delete"))
+	assert.False(t, request.AuthenticatedService{}.HasScope("surveys:read"))
+}
+
+func TestServiceFromCertificate(t *testing.T) {
+	service, err := request.ServiceFromCertificate(newClientCertificate(t))
+	require.NoError(t, err)
+	assert.Equal(t, newAuthenticatedService(), service)
+
+	_, err = request.ServiceFromCertificate(&x509.Certificate{})
+	assert.Error(t, err)
+}
+
+func TestAuthenticatedServiceHTTPMiddleware(t *testing.T) {
+	serve := func(req *http.Request, opts ...request.ServiceAuthenticationOption) (*httptest.ResponseRecorder, *request.AuthenticatedService) {
+		var service *request.AuthenticatedService
+
+		handler := request.NewAuthenticatedServiceHTTPMiddleware(opts...)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if s, ok := request.AuthenticatedServiceFromContext(r.Context()); ok {
+				service = &s
+			}
+		}))
+
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+
+		return w, service
+	}
+
+	t.Run("authenticates a verified client certificate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{newClientCertificate(t)}},
+		}
+
+		w, service := serve(req, request.WithClientCertificates(nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, service)
+		assert.Equal(t, newAuthenticatedService(), *service)
+	})
+
+	t.Run("ignores unverified client certificates", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{newClientCertificate(t)},
+		}
+
+		w, service := serve(req, request.WithClientCertificates(nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, service)
+	})
+
+	t.Run("rejects a certificate the mapper doesn't accept", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.TLS = &tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{}}},
+		}
+
+		w, service := serve(req, request.WithClientCertificates(nil))
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Equal(t, `{"errors":[{"status":"401","title":"Unauthorized"}]}`, w.Body.String())
+		assert.Nil(t, service)
+	})
+
+	t.Run("authenticates a service token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.Header.Set(request.ServiceTokenHeader, "valid-token")
+
+		w, service := serve(req, request.WithServiceTokens(verifyTestToken))
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, service)
+		assert.Equal(t, newAuthenticatedService(), *service)
+	})
+
+	t.Run("rejects an invalid service token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+		req.Header.Set(request.ServiceTokenHeader, "invalid-token")
+
+		var rejection error
+		w, service := serve(req,
+			request.WithServiceTokens(verifyTestToken),
+			request.WithOnUnauthenticatedService(func(w http.ResponseWriter, r *http.Request, err error) {
+				rejection = err
+				w.WriteHeader(http.StatusForbidden)
+			}))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.ErrorIs(t, rejection, request.ErrUnauthenticatedService)
+		assert.Nil(t, service)
+	})
+
+	t.Run("passes through requests without credentials", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+
+		w, service := serve(req, request.WithServiceTokens(verifyTestToken))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Nil(t, service)
+	})
+
+	t.Run("rejects requests without credentials when required", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+
+		w, service := serve(req,
+			request.WithServiceTokens(verifyTestToken),
+			request.WithServiceAuthenticationRequired())
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Nil(t, service)
+	})
+}
+
+func ExampleNewAuthenticatedServiceHTTPMiddleware() {
+	mw := request.NewAuthenticatedServiceHTTPMiddleware(
+		request.WithClientCertificates(nil),
+		request.WithServiceTokens(func(ctx context.Context, token string) (request.AuthenticatedService, error) {
+			// Verify the token with the identity provider.
+			return request.AuthenticatedService{Name: "murmur", Scopes: []string{"surveys:read"}}, nil
+		}),
+		request.WithServiceAuthenticationRequired())
+
+	handler := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		service, _ := request.AuthenticatedServiceFromContext(r.Context())
+		fmt.Println(service.Name)
+		fmt.Println(service.HasScope("surveys:read"))
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "https://example.com", nil)
+	req.Header.Set(request.ServiceTokenHeader, "token")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	// Output:
+	// murmur
+	// true
+}
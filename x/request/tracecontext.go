@@ -0,0 +1,142 @@
+package request
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Keys of the W3C baggage members, and OpenTelemetry span attributes, that
+// hold RequestIDs.
+const (
+	BaggageRequestIDKey     = "ca.request_id"
+	BaggageCorrelationIDKey = "ca.correlation_id"
+)
+
+// traceContextPropagator reads and writes the W3C traceparent, tracestate and
+// baggage headers.
+var traceContextPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// ContextWithRequestIDsBaggage returns a copy of the context whose
+// OpenTelemetry baggage includes the RequestIDs in the context, so that they
+// are propagated by OpenTelemetry instrumentation alongside the trace. The
+// context is returned unchanged if it has no RequestIDs.
+func ContextWithRequestIDsBaggage(ctx context.Context) (context.Context, error) {
+	ids, ok := RequestIDsFromContext(ctx)
+	if !ok {
+		return ctx, nil
+	}
+
+	b := baggage.FromContext(ctx)
+
+	for key, value := range map[string]string{
+		BaggageRequestIDKey:     ids.RequestID,
+		BaggageCorrelationIDKey: ids.CorrelationID,
+	} {
+		if value == "" {
+			continue
+		}
+
+		member, err := baggage.NewMember(key, url.QueryEscape(value))
+		if err != nil {
+			return ctx, fmt.Errorf("create baggage member %s: %w", key, err)
+		}
+
+		b, err = b.SetMember(member)
+		if err != nil {
+			return ctx, fmt.Errorf("set baggage member %s: %w", key, err)
+		}
+	}
+
+	return baggage.ContextWithBaggage(ctx, b), nil
+}
+
+// RequestIDsFromTraceContext derives RequestIDs from the OpenTelemetry
+// baggage and span context in the context. Each identifier is taken from its
+// baggage member if present. Otherwise, the correlation ID is the trace ID
+// and the request ID is the span ID, so that requests from services that
+// don't use this package can still be correlated with their traces. The
+// boolean is false if neither baggage nor a valid span context is present.
+func RequestIDsFromTraceContext(ctx context.Context) (RequestIDs, bool) {
+	b := baggage.FromContext(ctx)
+	ids := RequestIDs{
+		RequestID:     b.Member(BaggageRequestIDKey).Value(),
+		CorrelationID: b.Member(BaggageCorrelationIDKey).Value(),
+	}
+
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		if ids.RequestID == "" {
+			ids.RequestID = sc.SpanID().String()
+		}
+
+		if ids.CorrelationID == "" {
+			ids.CorrelationID = sc.TraceID().String()
+		}
+	}
+
+	return ids, ids.RequestID != "" || ids.CorrelationID != ""
+}
+
+// InjectTraceContext writes the W3C traceparent, tracestate and baggage
+// headers for the span context and baggage in the context to the carrier,
+// adding the RequestIDs in the context to the baggage. Any Carrier can be
+// used, including HeaderCarrier and the message attribute carriers.
+func InjectTraceContext(ctx context.Context, carrier Carrier) error {
+	ctx, err := ContextWithRequestIDsBaggage(ctx)
+	if err != nil {
+		return err
+	}
+
+	traceContextPropagator.Inject(ctx, carrier)
+
+	return nil
+}
+
+// ExtractTraceContext reads the W3C traceparent, tracestate and baggage
+// headers from the carrier, returning a copy of the context with the remote
+// span context and baggage, and with RequestIDs derived from them by
+// RequestIDsFromTraceContext. RequestIDs already in the context are replaced
+// only if the carrier holds a trace context or request ID baggage. Supply
+// WithValidation to check request IDs read from the baggage.
+func ExtractTraceContext(ctx context.Context, carrier Carrier, opts ...ExtractOption) context.Context {
+	cfg := newExtractConfig(opts)
+
+	ctx = traceContextPropagator.Extract(ctx, carrier)
+
+	// Only derive RequestIDs from what the carrier holds, not from a span
+	// context or baggage that was already in the context.
+	fromCarrier := traceContextPropagator.Extract(context.Background(), carrier)
+	if ids, ok := RequestIDsFromTraceContext(fromCarrier); ok {
+		if ids, ok := cfg.checkRequestIDs(ctx, ids); ok {
+			ctx = ContextWithRequestIDs(ctx, ids)
+		}
+	}
+
+	return ctx
+}
+
+// RequestIDsAttributes returns span attributes for the RequestIDs, so that
+// spans can be found from the IDs recorded by services that don't use
+// OpenTelemetry:
+//   span.SetAttributes(request.RequestIDsAttributes(ids)...)
+func RequestIDsAttributes(ids RequestIDs) []attribute.KeyValue {
+	var attrs []attribute.KeyValue
+
+	if ids.RequestID != "" {
+		attrs = append(attrs, attribute.String(BaggageRequestIDKey, ids.RequestID))
+	}
+
+	if ids.CorrelationID != "" {
+		attrs = append(attrs, attribute.String(BaggageCorrelationIDKey, ids.CorrelationID))
+	}
+
+	return attrs
+}
@@ -0,0 +1,172 @@
+package request_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	testTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	testTraceID     = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID      = "00f067aa0ba902b7"
+)
+
+func newSpanContext(t *testing.T) trace.SpanContext {
+	t.Helper()
+
+	traceID, err := trace.TraceIDFromHex(testTraceID)
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex(testSpanID)
+	require.NoError(t, err)
+
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+func TestContextWithRequestIDsBaggage(t *testing.T) {
+	t.Run("adds request IDs to the baggage", func(t *testing.T) {
+		ctx := request.ContextWithRequestIDs(context.Background(), request.RequestIDs{
+			RequestID:     "request id/1",
+			CorrelationID: "456",
+		})
+
+		ctx, err := request.ContextWithRequestIDsBaggage(ctx)
+		require.NoError(t, err)
+
+		b := baggage.FromContext(ctx)
+		assert.Equal(t, "request id/1", b.Member(request.BaggageRequestIDKey).Value())
+		assert.Equal(t, "456", b.Member(request.BaggageCorrelationIDKey).Value())
+	})
+
+	t.Run("keeps existing baggage", func(t *testing.T) {
+		member, err := baggage.NewMember("tenant", "acme")
+		require.NoError(t, err)
+		b, err := baggage.New(member)
+		require.NoError(t, err)
+
+		ctx := baggage.ContextWithBaggage(context.Background(), b)
+		ctx = request.ContextWithRequestIDs(ctx, request.RequestIDs{RequestID: "123"})
+
+		ctx, err = request.ContextWithRequestIDsBaggage(ctx)
+		require.NoError(t, err)
+
+		b = baggage.FromContext(ctx)
+		assert.Equal(t, "acme", b.Member("tenant").Value())
+		assert.Equal(t, "123", b.Member(request.BaggageRequestIDKey).Value())
+		assert.Empty(t, b.Member(request.BaggageCorrelationIDKey).Key())
+	})
+
+	t.Run("leaves the context unchanged without request IDs", func(t *testing.T) {
+		ctx, err := request.ContextWithRequestIDsBaggage(context.Background())
+		require.NoError(t, err)
+		assert.Zero(t, baggage.FromContext(ctx).Len())
+	})
+}
+
+func TestRequestIDsFromTraceContext(t *testing.T) {
+	t.Run("prefers baggage", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), newSpanContext(t))
+		ctx = request.ContextWithRequestIDs(ctx, newRequestIDs())
+		ctx, err := request.ContextWithRequestIDsBaggage(ctx)
+		require.NoError(t, err)
+
+		ids, ok := request.RequestIDsFromTraceContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, newRequestIDs(), ids)
+	})
+
+	t.Run("falls back to the span context", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), newSpanContext(t))
+
+		ids, ok := request.RequestIDsFromTraceContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, request.RequestIDs{RequestID: testSpanID, CorrelationID: testTraceID}, ids)
+	})
+
+	t.Run("returns false without trace context", func(t *testing.T) {
+		_, ok := request.RequestIDsFromTraceContext(context.Background())
+		assert.False(t, ok)
+	})
+}
+
+func TestInjectExtractTraceContext(t *testing.T) {
+	t.Run("round-trips the trace context and request IDs", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), newSpanContext(t))
+		ctx = request.ContextWithRequestIDs(ctx, newRequestIDs())
+
+		header := http.Header{}
+		require.NoError(t, request.InjectTraceContext(ctx, request.HeaderCarrier(header)))
+		assert.Equal(t, testTraceparent, header.Get("traceparent"))
+		assert.Contains(t, header.Get("baggage"), "ca.request_id=123")
+
+		extracted := request.ExtractTraceContext(context.Background(), request.HeaderCarrier(header))
+
+		sc := trace.SpanContextFromContext(extracted)
+		assert.True(t, sc.IsRemote())
+		assert.Equal(t, testTraceID, sc.TraceID().String())
+
+		ids, ok := request.RequestIDsFromContext(extracted)
+		assert.True(t, ok)
+		assert.Equal(t, newRequestIDs(), ids)
+	})
+
+	t.Run("derives request IDs from a traceparent without baggage", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("traceparent", testTraceparent)
+
+		ctx := request.ExtractTraceContext(context.Background(), request.HeaderCarrier(header))
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, request.RequestIDs{RequestID: testSpanID, CorrelationID: testTraceID}, ids)
+	})
+
+	t.Run("keeps existing request IDs if the carrier has no trace context", func(t *testing.T) {
+		ctx := trace.ContextWithSpanContext(context.Background(), newSpanContext(t))
+		ctx = request.ContextWithRequestIDs(ctx, newRequestIDs())
+
+		ctx = request.ExtractTraceContext(ctx, request.MapCarrier{})
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, newRequestIDs(), ids)
+	})
+}
+
+func TestRequestIDsAttributes(t *testing.T) {
+	assert.Equal(t, []attribute.KeyValue{
+		attribute.String(request.BaggageRequestIDKey, "123"),
+		attribute.String(request.BaggageCorrelationIDKey, "456"),
+	}, request.RequestIDsAttributes(newRequestIDs()))
+
+	assert.Empty(t, request.RequestIDsAttributes(request.RequestIDs{}))
+}
+
+func ExampleExtractTraceContext() {
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set("baggage", "ca.request_id=123,ca.correlation_id=456")
+
+	ctx := request.ExtractTraceContext(context.Background(), request.HeaderCarrier(header))
+
+	if ids, ok := request.RequestIDsFromContext(ctx); ok {
+		fmt.Println(ids.RequestID)
+		fmt.Println(ids.CorrelationID)
+	}
+
+	// Output:
+	// 123
+	// 456
+}
@@ -0,0 +1,304 @@
+package request
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const defaultMaxIdentifierLength = 128
+
+// ErrInvalidIdentifier is wrapped by the errors returned when RequestIDs or
+// an AuthenticatedUser fail validation.
+var ErrInvalidIdentifier = errors.New("invalid identifier")
+
+// InvalidField describes an identifier that failed validation.
+type InvalidField struct {
+	// Name is the name of the struct field, for example "RequestID".
+	Name string
+	// Reason describes why the value is invalid.
+	Reason string
+}
+
+// ValidationError is returned by Validate, listing every invalid identifier.
+// It wraps ErrInvalidIdentifier. Invalid values are not included, so that the
+// error can be logged safely.
+type ValidationError struct {
+	Fields []InvalidField
+}
+
+// Error implements error.
+func (e *ValidationError) Error() string {
+	reasons := make([]string, len(e.Fields))
+	for i, field := range e.Fields {
+		reasons[i] = field.Name + ": " + field.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidIdentifier, strings.Join(reasons, "; "))
+}
+
+// Unwrap returns ErrInvalidIdentifier.
+func (e *ValidationError) Unwrap() error {
+	return ErrInvalidIdentifier
+}
+
+// Invalid returns whether the named field failed validation.
+func (e *ValidationError) Invalid(name string) bool {
+	for _, field := range e.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+type validationConfig struct {
+	requireUUID bool
+	maxLength   int
+	allowed     func(r rune) bool
+}
+
+// ValidationOption is a function type that can be provided to Validate, and
+// to WithValidation, to configure the rules identifiers must follow. By
+// default, identifiers must be at most 128 characters of ASCII letters,
+// digits, '-', '_', '.' and ':'.
+type ValidationOption func(c *validationConfig)
+
+// WithUUIDFormat requires identifiers to be UUIDs in their canonical
+// 36-character form, such as the aggregate IDs used by most services.
+func WithUUIDFormat() ValidationOption {
+	return func(c *validationConfig) {
+		c.requireUUID = true
+	}
+}
+
+// WithMaxLength limits identifiers to the given number of characters.
+func WithMaxLength(n int) ValidationOption {
+	return func(c *validationConfig) {
+		c.maxLength = n
+	}
+}
+
+// WithAllowedCharacters restricts identifiers to the characters for which
+// allowed returns true.
+func WithAllowedCharacters(allowed func(r rune) bool) ValidationOption {
+	return func(c *validationConfig) {
+		c.allowed = allowed
+	}
+}
+
+func newValidationConfig(opts []ValidationOption) *validationConfig {
+	cfg := &validationConfig{
+		maxLength: defaultMaxIdentifierLength,
+		allowed:   isDefaultIdentifierCharacter,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+func isDefaultIdentifierCharacter(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	default:
+		return strings.ContainsRune("-_.:", r)
+	}
+}
+
+// check returns why the value is invalid, or an empty string if it is valid.
+func (c *validationConfig) check(value string) string {
+	if utf8.RuneCountInString(value) > c.maxLength {
+		return fmt.Sprintf("longer than %d characters", c.maxLength)
+	}
+
+	if c.requireUUID {
+		if _, err := uuid.Parse(value); err != nil || len(value) != 36 {
+			return "not a UUID"
+		}
+
+		return ""
+	}
+
+	for _, r := range value {
+		if !c.allowed(r) {
+			return fmt.Sprintf("contains disallowed character %q", r)
+		}
+	}
+
+	return ""
+}
+
+// validate checks each named field, appending those that are invalid to the
+// error. Empty values are valid unless the field is required.
+func (c *validationConfig) validate(verr *ValidationError, name, value string, required bool) {
+	if value == "" {
+		if required {
+			verr.Fields = append(verr.Fields, InvalidField{Name: name, Reason: "required"})
+		}
+		return
+	}
+
+	if reason := c.check(value); reason != "" {
+		verr.Fields = append(verr.Fields, InvalidField{Name: name, Reason: reason})
+	}
+}
+
+// Validate checks the RequestIDs against the validation rules, returning a
+// *ValidationError listing the invalid identifiers, if any. Either identifier
+// may be empty.
+func (ids RequestIDs) Validate(opts ...ValidationOption) error {
+	cfg := newValidationConfig(opts)
+	verr := &ValidationError{}
+
+	cfg.validate(verr, "RequestID", ids.RequestID, false)
+	cfg.validate(verr, "CorrelationID", ids.CorrelationID, false)
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// Validate checks the AuthenticatedUser against the validation rules,
+// returning a *ValidationError listing the invalid identifiers, if any. The
+// UserID is required; the CustomerAccountID and RealUserID may be empty.
+func (u AuthenticatedUser) Validate(opts ...ValidationOption) error {
+	cfg := newValidationConfig(opts)
+	verr := &ValidationError{}
+
+	cfg.validate(verr, "CustomerAccountID", u.CustomerAccountID, false)
+	cfg.validate(verr, "UserID", u.UserID, true)
+	cfg.validate(verr, "RealUserID", u.RealUserID, false)
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	return nil
+}
+
+// InvalidIdentifierHandler is called when identifiers read by Extract or
+// ExtractTraceContext fail validation, for example to log the error or report
+// it with errorreport.ReportError.
+type InvalidIdentifierHandler func(ctx context.Context, err error)
+
+type extractConfig struct {
+	validate          bool
+	validation        []ValidationOption
+	replaceRequestIDs bool
+	onInvalid         InvalidIdentifierHandler
+}
+
+// ExtractOption is a function type that can be provided to Extract and
+// ExtractTraceContext to configure how identifiers read from a carrier are
+// checked.
+type ExtractOption func(c *extractConfig)
+
+// WithValidation validates the identifiers read from the carrier with the
+// given rules. Invalid request and correlation IDs are discarded, unless
+// WithReplacedRequestIDs is supplied, and an AuthenticatedUser with any
+// invalid identifier is not added to the context.
+func WithValidation(opts ...ValidationOption) ExtractOption {
+	return func(c *extractConfig) {
+		c.validate = true
+		c.validation = opts
+	}
+}
+
+// WithoutValidation accepts identifiers read from the carrier without
+// checking them. It turns off the validation that NewRequestIDHTTPMiddleware
+// applies by default, for example for a service that only receives requests
+// from trusted callers whose identifiers don't follow the default rules.
+func WithoutValidation() ExtractOption {
+	return func(c *extractConfig) {
+		c.validate = false
+		c.validation = nil
+	}
+}
+
+// WithReplacedRequestIDs replaces invalid request and correlation IDs with
+// new IDs from NewID instead of discarding them, so the request can still be
+// traced through the services it calls.
+func WithReplacedRequestIDs() ExtractOption {
+	return func(c *extractConfig) {
+		c.replaceRequestIDs = true
+	}
+}
+
+// WithInvalidIdentifierHandler configures the handler called with the
+// *ValidationError for identifiers that fail validation.
+func WithInvalidIdentifierHandler(handler InvalidIdentifierHandler) ExtractOption {
+	return func(c *extractConfig) {
+		c.onInvalid = handler
+	}
+}
+
+func newExtractConfig(opts []ExtractOption) *extractConfig {
+	cfg := &extractConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// checkRequestIDs validates the RequestIDs read from a carrier, discarding or
+// replacing invalid identifiers. The boolean is false if no identifiers
+// remain.
+func (c *extractConfig) checkRequestIDs(ctx context.Context, ids RequestIDs) (RequestIDs, bool) {
+	if c.validate {
+		var verr *ValidationError
+		if err := ids.Validate(c.validation...); errors.As(err, &verr) {
+			c.report(ctx, err)
+
+			ids.RequestID = c.replacement(ids.RequestID, verr.Invalid("RequestID"))
+			ids.CorrelationID = c.replacement(ids.CorrelationID, verr.Invalid("CorrelationID"))
+		}
+	}
+
+	return ids, ids.RequestID != "" || ids.CorrelationID != ""
+}
+
+func (c *extractConfig) replacement(value string, invalid bool) string {
+	switch {
+	case !invalid:
+		return value
+	case c.replaceRequestIDs:
+		return NewID()
+	default:
+		return ""
+	}
+}
+
+// checkUser returns whether the AuthenticatedUser read from a carrier should
+// be added to the context: it must have a user ID, and be valid if validation
+// is enabled.
+func (c *extractConfig) checkUser(ctx context.Context, user AuthenticatedUser) bool {
+	if user.UserID == "" {
+		return false
+	}
+
+	if c.validate {
+		if err := user.Validate(c.validation...); err != nil {
+			c.report(ctx, err)
+			return false
+		}
+	}
+
+	return true
+}
+
+func (c *extractConfig) report(ctx context.Context, err error) {
+	if c.onInvalid != nil {
+		c.onInvalid(ctx, err)
+	}
+}
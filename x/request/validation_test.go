@@ -0,0 +1,160 @@
+package request_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testUUID = "0d5d5c8c-59bd-4a7c-9d3c-5cbd1b0e0f4a"
+
+func TestRequestIDsValidate(t *testing.T) {
+	t.Run("accepts valid identifiers", func(t *testing.T) {
+		assert.NoError(t, newRequestIDs().Validate())
+		assert.NoError(t, request.RequestIDs{}.Validate())
+		assert.NoError(t, request.RequestIDs{RequestID: "req_1.a:b-c"}.Validate())
+	})
+
+	t.Run("lists every invalid identifier", func(t *testing.T) {
+		err := request.RequestIDs{
+			RequestID:     "abc\ndef",
+			CorrelationID: strings.Repeat("a", 129),
+		}.Validate()
+
+		assert.ErrorIs(t, err, request.ErrInvalidIdentifier)
+
+		var verr *request.ValidationError
+		require.True(t, errors.As(err, &verr))
+		assert.Equal(t, []request.InvalidField{
+			{Name: "RequestID", Reason: `contains disallowed character '\n'`},
+			{Name: "CorrelationID", Reason: "longer than 128 characters"},
+		}, verr.Fields)
+		assert.Equal(t, `invalid identifier: RequestID: contains disallowed character '\n'; CorrelationID: longer than 128 characters`, err.Error())
+	})
+
+	t.Run("applies options", func(t *testing.T) {
+		assert.Error(t, request.RequestIDs{RequestID: "abcdef"}.Validate(request.WithMaxLength(5)))
+		assert.NoError(t, request.RequestIDs{RequestID: "a b"}.Validate(request.WithAllowedCharacters(unicode.IsPrint)))
+
+		assert.NoError(t, request.RequestIDs{RequestID: testUUID}.Validate(request.WithUUIDFormat()))
+		assert.Error(t, request.RequestIDs{RequestID: "123"}.Validate(request.WithUUIDFormat()))
+		assert.Error(t, request.RequestIDs{RequestID: "urn:uuid:" + testUUID}.Validate(request.WithUUIDFormat()))
+	})
+}
+
+func TestAuthenticatedUserValidate(t *testing.T) {
+	assert.NoError(t, newAuthenticatedUser().Validate())
+	assert.NoError(t, request.AuthenticatedUser{UserID: testUUID}.Validate(request.WithUUIDFormat()))
+
+	err := request.AuthenticatedUser{CustomerAccountID: "not a uuid"}.Validate(request.WithUUIDFormat())
+
+	var verr *request.ValidationError
+	require.True(t, errors.As(err, &verr))
+	assert.Equal(t, []request.InvalidField{
+		{Name: "CustomerAccountID", Reason: "not a UUID"},
+		{Name: "UserID", Reason: "required"},
+	}, verr.Fields)
+	assert.True(t, verr.Invalid("UserID"))
+	assert.False(t, verr.Invalid("RealUserID"))
+}
+
+func TestExtractWithValidation(t *testing.T) {
+	newCarrier := func() request.MapCarrier {
+		return request.MapCarrier{
+			request.RequestIDKey:     "<script>",
+			request.CorrelationIDKey: "456",
+			request.UserIDKey:        "456",
+			request.AccountIDKey:     "123; DROP TABLE",
+		}
+	}
+
+	t.Run("does not validate by default", func(t *testing.T) {
+		ctx := request.Extract(context.Background(), newCarrier())
+
+		ids, _ := request.RequestIDsFromContext(ctx)
+		assert.Equal(t, "<script>", ids.RequestID)
+		assert.True(t, request.ContextHasAuthenticatedUser(ctx))
+	})
+
+	t.Run("discards invalid identifiers and reports them", func(t *testing.T) {
+		var reported []error
+		ctx := request.Extract(context.Background(), newCarrier(),
+			request.WithValidation(),
+			request.WithInvalidIdentifierHandler(func(ctx context.Context, err error) {
+				reported = append(reported, err)
+			}))
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, request.RequestIDs{CorrelationID: "456"}, ids)
+		assert.False(t, request.ContextHasAuthenticatedUser(ctx))
+
+		require.Len(t, reported, 2)
+		for _, err := range reported {
+			assert.ErrorIs(t, err, request.ErrInvalidIdentifier)
+		}
+	})
+
+	t.Run("replaces invalid request IDs", func(t *testing.T) {
+		ctx := request.Extract(context.Background(), newCarrier(),
+			request.WithValidation(),
+			request.WithReplacedRequestIDs())
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, "456", ids.CorrelationID)
+		_, err := uuid.Parse(ids.RequestID)
+		assert.NoError(t, err)
+	})
+
+	t.Run("validates trace context baggage", func(t *testing.T) {
+		header := http.Header{}
+		header.Set("baggage", "ca.request_id=a/b,ca.correlation_id=456")
+
+		ctx := request.ExtractTraceContext(context.Background(), request.HeaderCarrier(header), request.WithValidation())
+
+		ids, ok := request.RequestIDsFromContext(ctx)
+		assert.True(t, ok)
+		assert.Equal(t, request.RequestIDs{CorrelationID: "456"}, ids)
+	})
+}
+
+func ExampleAuthenticatedUser_Validate() {
+	user := request.AuthenticatedUser{
+		CustomerAccountID: "0d5d5c8c-59bd-4a7c-9d3c-5cbd1b0e0f4a",
+		UserID:            "bob",
+	}
+
+	if err := user.Validate(request.WithUUIDFormat()); err != nil {
+		fmt.Println(err)
+	}
+
+	// Output:
+	// invalid identifier: UserID: not a UUID
+}
+
+func ExampleWithValidation() {
+	header := http.Header{}
+	header.Set("X-Request-Id", "not-a-uuid")
+
+	ctx := request.Extract(context.Background(), request.HeaderCarrier(header),
+		request.WithValidation(request.WithUUIDFormat()),
+		request.WithInvalidIdentifierHandler(func(ctx context.Context, err error) {
+			fmt.Println(err)
+		}))
+
+	fmt.Println(request.ContextHasRequestIDs(ctx))
+
+	// Output:
+	// invalid identifier: RequestID: not a UUID
+	// false
+}
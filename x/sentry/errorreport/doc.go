@@ -18,11 +18,18 @@
 // Ad-hoc errors can be reported using ReportError():
 //   errorreport.ReportError(ctx, errors.New("We hit a snag!"))
 //
+// ReportErrorWithTags() adds tags to a single report:
+//   errorreport.ReportErrorWithTags(ctx, err, map[string]string{"message_id": id})
+//
 // HTTP middleware can be used. Passing in nil uses the default panic handler.
 // See the OnRequestPanicHandler type if you wish to supply your own.
 //   mw := middleware.NewHTTPMiddleware(nil)
 //   mw(myHTTPHandler)
 //
+// NewHTTPRecoverMiddleware also recovers and reports panics, and can be
+// applied outside other middleware, including NewHTTPMiddleware, so that
+// panics in that middleware are reported too.
+//
 // Goa middleware can be used, allowing errors to be reported automatically from
 // Goa applications:
 //   mw := errorreport.NewGoaMiddleware()
@@ -31,6 +38,19 @@
 // This is recommended when using Goa, as it offers reporting of all errors
 // returned from the generated logic types.
 //
-// This package also supports middleware for Lambda functions:
-//   mw := errorreport.NewLambdaMiddleware(errorreport.LambdaErrorOptions{})
+// gRPC servers can use interceptors, which report errors with server fault
+// status codes (Unknown, Internal and DataLoss) and recover panics, returning
+// an Internal status error to the client:
+//   grpc.NewServer(
+//     grpc.ChainUnaryInterceptor(
+//       request.NewGRPCUnaryServerInterceptor(),
+//       errorreport.NewGRPCUnaryServerInterceptor()),
+//     grpc.ChainStreamInterceptor(
+//       request.NewGRPCStreamServerInterceptor(),
+//       errorreport.NewGRPCStreamServerInterceptor()))
+//
+// This package also supports middleware for Lambda functions, which can be
+// composed with other middleware by lambdafunction.Chain:
+//   mw := errorreport.NewLambdaMiddleware[events.SQSEvent](errorreport.WithRepanic(false))
+//   lambda.Start(mw(handler))
 package errorreport
@@ -11,6 +11,7 @@ import (
 
 const (
 	sentryTracingSubheading = "Culture Amp - Tracing"
+	sentryServiceSubheading = "Culture Amp - Calling Service"
 )
 
 // Init initialises the Sentry client with the given options. It returns
@@ -71,14 +72,25 @@ func Init(opts ...Option) error {
 }
 
 // ReportError reports an error to Sentry. It will attempt to
-// extract request IDs and the authenticated user from the
-// context.
+// extract request IDs, the authenticated user or service and request
+// metadata from the context.
 func ReportError(ctx context.Context, err error) {
-	scope := sentry.CurrentHub().PushScope()
-	defer sentry.PopScope()
+	ReportErrorWithTags(ctx, err, nil)
+}
 
-	addRequestFieldsToScope(ctx, scope)
-	sentry.CaptureException(err)
+// ReportErrorWithTags reports an error to Sentry in the same way as
+// ReportError, adding the given tags to the event. Unlike Decorate, the tags
+// are only added to this report, so it is safe to use when errors are
+// reported concurrently.
+func ReportErrorWithTags(ctx context.Context, err error, tags map[string]string) {
+	// The error is captured by a clone of the current hub, so that the scope
+	// isn't shared with concurrent reports.
+	hub := sentry.CurrentHub().Clone()
+	hub.WithScope(func(scope *sentry.Scope) {
+		addRequestFieldsToScope(ctx, scope)
+		scope.SetTags(tags)
+		hub.CaptureException(err)
+	})
 }
 
 // Decorate creates a new Sentry scope and adds the supplied tags. This allows
@@ -93,15 +105,40 @@ func Decorate(tags map[string]string) func() {
 }
 
 func addRequestFieldsToScope(ctx context.Context, scope *sentry.Scope) {
+	var user sentry.User
+
 	if authenticatedUser, ok := request.AuthenticatedUserFromContext(ctx); ok {
-		scope.SetUser(sentry.User{
-			ID: authenticatedUser.UserID,
-		})
+		user.ID = authenticatedUser.UserID
 
 		scope.SetTag("customer", authenticatedUser.CustomerAccountID)
 		scope.SetTag("user.real", authenticatedUser.RealUserID)
 	}
 
+	if service, ok := request.AuthenticatedServiceFromContext(ctx); ok {
+		scope.SetTag("caller.service", service.Name)
+		setTagIfNotEmpty(scope, "caller.client_id", service.ClientID)
+
+		scope.SetContext(sentryServiceSubheading, map[string]interface{}{
+			"Name":     service.Name,
+			"ClientID": service.ClientID,
+			"Scopes":   service.Scopes,
+		})
+	}
+
+	if metadata, ok := request.RequestMetadataFromContext(ctx); ok {
+		user.IPAddress = metadata.SourceIP
+
+		setTagIfNotEmpty(scope, "locale", metadata.Locale)
+		setTagIfNotEmpty(scope, "timezone", metadata.Timezone)
+		setTagIfNotEmpty(scope, "client.application", metadata.ClientApplication)
+		setTagIfNotEmpty(scope, "client.version", metadata.ClientVersion)
+		setTagIfNotEmpty(scope, "user_agent", metadata.UserAgent)
+	}
+
+	if user != (sentry.User{}) {
+		scope.SetUser(user)
+	}
+
 	if requestIDs, ok := request.RequestIDsFromContext(ctx); ok {
 		scope.SetTag("RequestID", requestIDs.RequestID)
 
@@ -112,3 +149,9 @@ func addRequestFieldsToScope(ctx context.Context, scope *sentry.Scope) {
 		})
 	}
 }
+
+func setTagIfNotEmpty(scope *sentry.Scope, key, value string) {
+	if value != "" {
+		scope.SetTag(key, value)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/cultureamp/ca-go/x/request"
 	"github.com/cultureamp/ca-go/x/sentry/errorreport"
 	"github.com/getsentry/sentry-go"
 	"github.com/stretchr/testify/assert"
@@ -46,6 +47,77 @@ func TestDecorate(t *testing.T) {
 	assert.Equal(t, "", eventNoTag.Tags["animal"])
 }
 
+func TestReportErrorWithTags(t *testing.T) {
+	ctx := context.Background()
+	mockSentryTransport := setupMockSentryTransport(t)
+
+	errorreport.ReportErrorWithTags(ctx, errors.New("with a flamingo"), map[string]string{
+		"animal": "flamingo",
+	})
+	errorreport.ReportError(ctx, errors.New("i have no flamingo"))
+
+	require.Len(t, mockSentryTransport.events, 2)
+	assert.Equal(t, "flamingo", mockSentryTransport.events[0].Tags["animal"])
+	assert.Equal(t, "", mockSentryTransport.events[1].Tags["animal"])
+}
+
+func TestReportErrorWithRequestMetadata(t *testing.T) {
+	mockSentryTransport := setupMockSentryTransport(t)
+
+	ctx := request.ContextWithRequestMetadata(context.Background(), request.RequestMetadata{
+		Locale:            "en-AU",
+		Timezone:          "Australia/Melbourne",
+		ClientApplication: "performance-ui",
+		ClientVersion:     "1.2.3",
+		SourceIP:          "203.0.113.7",
+		UserAgent:         "Mozilla/5.0",
+	})
+	errorreport.ReportError(ctx, errors.New("with metadata"))
+
+	// Metadata without a user is still recorded...
+	errorreport.ReportError(request.ContextWithRequestMetadata(context.Background(), request.RequestMetadata{
+		Locale: "fr",
+	}), errors.New("with partial metadata"))
+
+	require.Len(t, mockSentryTransport.events, 2)
+
+	event := mockSentryTransport.events[0]
+	assert.Equal(t, "en-AU", event.Tags["locale"])
+	assert.Equal(t, "Australia/Melbourne", event.Tags["timezone"])
+	assert.Equal(t, "performance-ui", event.Tags["client.application"])
+	assert.Equal(t, "1.2.3", event.Tags["client.version"])
+	assert.Equal(t, "Mozilla/5.0", event.Tags["user_agent"])
+	assert.Equal(t, "203.0.113.7", event.User.IPAddress)
+
+	// ...and empty attributes are not tagged.
+	event = mockSentryTransport.events[1]
+	assert.Equal(t, "fr", event.Tags["locale"])
+	assert.NotContains(t, event.Tags, "timezone")
+	assert.Empty(t, event.User.IPAddress)
+}
+
+func TestReportErrorWithAuthenticatedService(t *testing.T) {
+	mockSentryTransport := setupMockSentryTransport(t)
+
+	ctx := request.ContextWithAuthenticatedService(context.Background(), request.AuthenticatedService{
+		Name:     "murmur",
+		ClientID: "spiffe://cultureamp.net/murmur",
+		Scopes:   []string{"surveys:read"},
+	})
+	errorreport.ReportError(ctx, errors.New("from a service"))
+
+	require.Len(t, mockSentryTransport.events, 1)
+
+	event := mockSentryTransport.events[0]
+	assert.Equal(t, "murmur", event.Tags["caller.service"])
+	assert.Equal(t, "spiffe://cultureamp.net/murmur", event.Tags["caller.client_id"])
+	assert.Empty(t, event.User.ID)
+
+	serviceContext, ok := event.Contexts["Culture Amp - Calling Service"].(map[string]interface{})
+	require.True(t, ok)
+	assert.Equal(t, []string{"surveys:read"}, serviceContext["Scopes"])
+}
+
 func TestConfigure(t *testing.T) {
 	t.Run("no errors when all mandatory options supplied", func(t *testing.T) {
 		err := errorreport.Init(
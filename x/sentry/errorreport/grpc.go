@@ -0,0 +1,80 @@
+package errorreport
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// grpcReportedCodes are the status codes that indicate a fault in the server,
+// so errors with these codes are reported to Sentry. Other codes, like
+// NotFound or InvalidArgument, describe a problem with the call. Errors that
+// are not gRPC status errors have the Unknown code.
+var grpcReportedCodes = map[codes.Code]bool{
+	codes.Unknown:  true,
+	codes.Internal: true,
+	codes.DataLoss: true,
+}
+
+// NewGRPCUnaryServerInterceptor returns a gRPC interceptor that reports
+// errors from unary calls to Sentry. Panics are recovered, reported, and
+// returned to the client as an Internal status error. Errors with status
+// codes describing a problem with the call, such as NotFound, are not
+// reported.
+//
+// Install it after the request interceptors, so that reports include the
+// request IDs and user:
+//   grpc.NewServer(grpc.ChainUnaryInterceptor(
+//     request.NewGRPCUnaryServerInterceptor(),
+//     errorreport.NewGRPCUnaryServerInterceptor()))
+func NewGRPCUnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer recoverGRPCPanic(ctx, info.FullMethod, &err)
+
+		resp, err = handler(ctx, req)
+		reportGRPCError(ctx, info.FullMethod, err)
+
+		return resp, err
+	}
+}
+
+// NewGRPCStreamServerInterceptor returns a gRPC interceptor that reports
+// errors from streaming calls to Sentry, in the same way as
+// NewGRPCUnaryServerInterceptor.
+func NewGRPCStreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		ctx := ss.Context()
+		defer recoverGRPCPanic(ctx, info.FullMethod, &err)
+
+		err = handler(srv, ss)
+		reportGRPCError(ctx, info.FullMethod, err)
+
+		return err
+	}
+}
+
+func recoverGRPCPanic(ctx context.Context, method string, err *error) {
+	if r := recover(); r != nil {
+		reportGRPC(ctx, method, codes.Internal, panicToError(r))
+		*err = status.Error(codes.Internal, "internal error")
+	}
+}
+
+func reportGRPCError(ctx context.Context, method string, err error) {
+	if err == nil {
+		return
+	}
+
+	if code := status.Code(err); grpcReportedCodes[code] {
+		reportGRPC(ctx, method, code, err)
+	}
+}
+
+func reportGRPC(ctx context.Context, method string, code codes.Code, err error) {
+	ReportErrorWithTags(ctx, err, map[string]string{
+		"grpc.method": method,
+		"grpc.code":   code.String(),
+	})
+}
@@ -0,0 +1,190 @@
+package errorreport_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/cultureamp/ca-go/x/request"
+	"github.com/cultureamp/ca-go/x/sentry/errorreport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// failingHealthServer fails each call in the way named by the requested
+// service.
+type failingHealthServer struct {
+	healthpb.UnimplementedHealthServer
+}
+
+func (s *failingHealthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	if err := fail(req.Service); err != nil {
+		return nil, err
+	}
+
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+func (s *failingHealthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if err := fail(req.Service); err != nil {
+		return err
+	}
+
+	return stream.Send(&healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING})
+}
+
+func fail(how string) error {
+	switch how {
+	case "panic":
+		panic("boom")
+	case "internal":
+		return status.Error(codes.Internal, "database unavailable")
+	case "not found":
+		return status.Error(codes.NotFound, "no such service")
+	case "plain":
+		return errors.New("plain error")
+	default:
+		return nil
+	}
+}
+
+func newGRPCTestClient(t *testing.T) healthpb.HealthClient {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			request.NewGRPCUnaryServerInterceptor(),
+			errorreport.NewGRPCUnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(
+			request.NewGRPCStreamServerInterceptor(),
+			errorreport.NewGRPCStreamServerInterceptor()))
+	healthpb.RegisterHealthServer(server, &failingHealthServer{})
+
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(request.NewGRPCUnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(request.NewGRPCStreamClientInterceptor()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return healthpb.NewHealthClient(conn)
+}
+
+func TestGRPCServerInterceptors(t *testing.T) {
+	client := newGRPCTestClient(t)
+
+	tests := []struct {
+		how          string
+		expectedCode codes.Code
+		reported     bool
+	}{
+		{how: "", expectedCode: codes.OK, reported: false},
+		{how: "panic", expectedCode: codes.Internal, reported: true},
+		{how: "internal", expectedCode: codes.Internal, reported: true},
+		{how: "plain", expectedCode: codes.Unknown, reported: true},
+		{how: "not found", expectedCode: codes.NotFound, reported: false},
+	}
+
+	for _, test := range tests {
+		test := test
+
+		t.Run("unary call: "+test.how, func(t *testing.T) {
+			mockSentryTransport := setupMockSentryTransport(t)
+			ctx, sentryContextAssertions := setupContextForSentry()
+
+			_, err := client.Check(ctx, &healthpb.HealthCheckRequest{Service: test.how})
+			assert.Equal(t, test.expectedCode, status.Code(err))
+
+			if !test.reported {
+				assert.Empty(t, mockSentryTransport.Events())
+				return
+			}
+
+			sentryContextAssertions(t, mockSentryTransport)
+			event := mockSentryTransport.Events()[0]
+			assert.Equal(t, "/grpc.health.v1.Health/Check", event.Tags["grpc.method"])
+			assert.Equal(t, test.expectedCode.String(), event.Tags["grpc.code"])
+		})
+
+		t.Run("streaming call: "+test.how, func(t *testing.T) {
+			mockSentryTransport := setupMockSentryTransport(t)
+			ctx, sentryContextAssertions := setupContextForSentry()
+
+			stream, err := client.Watch(ctx, &healthpb.HealthCheckRequest{Service: test.how})
+			require.NoError(t, err)
+			_, err = stream.Recv()
+			assert.Equal(t, test.expectedCode, status.Code(err))
+
+			if !test.reported {
+				assert.Empty(t, mockSentryTransport.Events())
+				return
+			}
+
+			sentryContextAssertions(t, mockSentryTransport)
+			assert.Equal(t, "/grpc.health.v1.Health/Watch", mockSentryTransport.Events()[0].Tags["grpc.method"])
+		})
+	}
+}
+
+func TestGRPCConcurrentReports(t *testing.T) {
+	mockSentryTransport := setupMockSentryTransport(t)
+	interceptor := errorreport.NewGRPCUnaryServerInterceptor()
+
+	const calls = 500
+
+	var (
+		start sync.WaitGroup
+		done  sync.WaitGroup
+	)
+	start.Add(1)
+	for i := 0; i < calls; i++ {
+		method := fmt.Sprintf("/test.Service/Method%d", i)
+
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			start.Wait()
+
+			_, _ = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{FullMethod: method},
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					return nil, errors.New(method)
+				})
+		}()
+	}
+	start.Done()
+	done.Wait()
+
+	// Each report is tagged with the method of its own call.
+	events := mockSentryTransport.Events()
+	require.Len(t, events, calls)
+	for _, event := range events {
+		require.Len(t, event.Exception, 1)
+		assert.Equal(t, event.Exception[0].Value, event.Tags["grpc.method"])
+	}
+}
+
+func TestGRPCPanicMessage(t *testing.T) {
+	setupMockSentryTransport(t)
+	client := newGRPCTestClient(t)
+
+	_, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "panic"})
+
+	// The panic value is not sent to the client.
+	assert.Equal(t, "internal error", status.Convert(err).Message())
+}
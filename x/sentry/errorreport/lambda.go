@@ -47,6 +47,15 @@ func LambdaMiddleware[TIn any](nextHandler lambdafunction.HandlerOf[TIn], config
 	}
 }
 
+// NewLambdaMiddleware[TIn] returns the error-handling middleware provided by
+// LambdaMiddleware as a lambdafunction.Middleware, so that it can be composed
+// with lambdafunction.Chain.
+func NewLambdaMiddleware[TIn any](config ...LambdaOption) lambdafunction.Middleware[TIn] {
+	return func(nextHandler lambdafunction.HandlerOf[TIn]) lambdafunction.HandlerOf[TIn] {
+		return LambdaMiddleware(nextHandler, config...)
+	}
+}
+
 // LambdaWithOutputMiddleware[TIn, TOut] provides error-handling middleware for
 // a Lambda function that has a payload type of TIn and returns the tuple TOut,error.
 func LambdaWithOutputMiddleware[TIn any, TOut any](nextHandler lambdafunction.HandlerWithOutputOf[TIn, TOut], config ...LambdaOption) lambdafunction.HandlerWithOutputOf[TIn, TOut] {
@@ -63,6 +72,16 @@ func LambdaWithOutputMiddleware[TIn any, TOut any](nextHandler lambdafunction.Ha
 	}
 }
 
+// NewLambdaWithOutputMiddleware[TIn, TOut] returns the error-handling
+// middleware provided by LambdaWithOutputMiddleware as a
+// lambdafunction.MiddlewareWithOutput, so that it can be composed with
+// lambdafunction.ChainWithOutput.
+func NewLambdaWithOutputMiddleware[TIn any, TOut any](config ...LambdaOption) lambdafunction.MiddlewareWithOutput[TIn, TOut] {
+	return func(nextHandler lambdafunction.HandlerWithOutputOf[TIn, TOut]) lambdafunction.HandlerWithOutputOf[TIn, TOut] {
+		return LambdaWithOutputMiddleware(nextHandler, config...)
+	}
+}
+
 func configure(config []LambdaOption) lambdaOptions {
 	options := lambdaOptions{
 		Repanic: true,
@@ -53,6 +53,33 @@ func TestHandleError(t *testing.T) {
 			assert.Equal(t, test.err, err)
 			assert.Len(t, mockSentryTransport.Events(), test.expectedSentryEvents)
 		})
+
+		t.Run("NewLambdaMiddleware: "+test.name, func(t *testing.T) {
+			mockSentryTransport := setupMockSentryTransport(t)
+			eventHandler := func(ctx context.Context, payload string) error {
+				return test.err
+			}
+			wrapped := errorreport.NewLambdaMiddleware[string]()(eventHandler)
+
+			err := wrapped(context.Background(), "random body")
+
+			assert.Equal(t, test.err, err)
+			assert.Len(t, mockSentryTransport.Events(), test.expectedSentryEvents)
+		})
+
+		t.Run("NewLambdaWithOutputMiddleware: "+test.name, func(t *testing.T) {
+			mockSentryTransport := setupMockSentryTransport(t)
+			eventHandler := func(ctx context.Context, payload string) (string, error) {
+				return "output", test.err
+			}
+			wrapped := errorreport.NewLambdaWithOutputMiddleware[string, string]()(eventHandler)
+
+			out, err := wrapped(context.Background(), "random body")
+
+			assert.Equal(t, "output", out)
+			assert.Equal(t, test.err, err)
+			assert.Len(t, mockSentryTransport.Events(), test.expectedSentryEvents)
+		})
 	}
 }
 
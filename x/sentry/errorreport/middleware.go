@@ -60,6 +60,42 @@ func NewHTTPMiddleware(onRequestPanic OnRequestPanicHandler) func(http.Handler)
 	}
 }
 
+// NewHTTPRecoverMiddleware returns HTTP middleware that recovers panics,
+// reports them with ReportError, and calls the OnRequestPanicHandler if
+// provided. If a handler is not provided, returns a JSON:API structured body
+// with status 500. Unlike NewHTTPMiddleware, it doesn't add a Sentry hub to
+// the request context, so it can be applied outside other middleware,
+// including NewHTTPMiddleware, to recover panics in that middleware. Panics
+// with http.ErrAbortHandler are not recovered, so the server still aborts the
+// response.
+func NewHTTPRecoverMiddleware(onRequestPanic OnRequestPanicHandler) func(http.Handler) http.Handler {
+	panicHandler := defaultRequestPanicHandler
+	if onRequestPanic != nil {
+		panicHandler = onRequestPanic
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			defer func() {
+				r := recover()
+				if r == nil {
+					return
+				}
+
+				if r == http.ErrAbortHandler {
+					panic(r)
+				}
+
+				err := panicToError(r)
+				ReportError(req.Context(), err)
+				panicHandler(req.Context(), w, err)
+			}()
+
+			next.ServeHTTP(w, req)
+		})
+	}
+}
+
 // NewGoaEndpointMiddleware returns Goa middleware to detect and report
 // errors to Sentry.
 func NewGoaEndpointMiddleware() func(goa.Endpoint) goa.Endpoint {
@@ -77,12 +113,16 @@ func NewGoaEndpointMiddleware() func(goa.Endpoint) goa.Endpoint {
 
 func recoverRequestPanic(ctx context.Context, w http.ResponseWriter, errorHandler func(context.Context, http.ResponseWriter, error)) {
 	if r := recover(); r != nil {
-		// convert to an error if it's not one already
-		err, ok := r.(error)
-		if !ok {
-			err = errors.New(fmt.Sprint(r))
-		}
+		errorHandler(ctx, w, panicToError(r))
+	}
+}
 
-		errorHandler(ctx, w, err)
+// panicToError converts a recovered value to an error if it's not one
+// already.
+func panicToError(r interface{}) error {
+	if err, ok := r.(error); ok {
+		return err
 	}
+
+	return errors.New(fmt.Sprint(r))
 }
@@ -138,6 +138,59 @@ func TestHTTPMiddleware(t *testing.T) {
 	})
 }
 
+func TestHTTPRecoverMiddleware(t *testing.T) {
+	ctx, sentryContextAssertions := setupContextForSentry()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://www.example.com/", nil)
+	require.NoError(t, err)
+
+	t.Run("recovers and reports panics", func(t *testing.T) {
+		mockSentryTransport := setupMockSentryTransport(t)
+		w := httptest.NewRecorder()
+
+		var panicErr error
+		mw := errorreport.NewHTTPRecoverMiddleware(func(c context.Context, w http.ResponseWriter, err error) {
+			panicErr = err
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})).ServeHTTP(w, req)
+
+		assert.EqualError(t, panicErr, "boom")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		sentryContextAssertions(t, mockSentryTransport)
+	})
+
+	t.Run("recovers panics in NewHTTPMiddleware only once", func(t *testing.T) {
+		mockSentryTransport := setupMockSentryTransport(t)
+		w := httptest.NewRecorder()
+
+		handler := errorreport.NewHTTPRecoverMiddleware(nil)(errorreport.NewHTTPMiddleware(nil)(
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			})))
+		handler.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		sentryContextAssertions(t, mockSentryTransport)
+	})
+
+	t.Run("does not recover http.ErrAbortHandler", func(t *testing.T) {
+		mockSentryTransport := setupMockSentryTransport(t)
+
+		handler := errorreport.NewHTTPRecoverMiddleware(nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			panic(http.ErrAbortHandler)
+		}))
+
+		assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+		})
+		assert.Empty(t, mockSentryTransport.Events())
+	})
+}
+
 func TestGoaEndpointMiddleware(t *testing.T) {
 	ctx, sentryContextAssertion := setupContextForSentry()
 